@@ -0,0 +1,37 @@
+package bri
+
+import (
+	"math/rand"
+	"time"
+)
+
+// FullJitterBackoff is heimdall.Backoff which waits a random duration between 0 and min(Max, Base * 2^retry).
+// Randomizing the whole interval spreads retries of many clients, so they don't hit BRI at the same time after an outage.
+// Set it as Client.Backoff to replace the default constant backoff.
+type FullJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// NewFullJitterBackoff creates full jitter backoff with base interval and max interval
+func NewFullJitterBackoff(base time.Duration, max time.Duration) *FullJitterBackoff {
+	return &FullJitterBackoff{
+		Base: base,
+		Max:  max,
+	}
+}
+
+// Next returns interval before the given retry, retry starts from 0
+func (b *FullJitterBackoff) Next(retry int) time.Duration {
+	if b.Base <= 0 || b.Max <= 0 {
+		return 0
+	}
+
+	ceiling := b.Max
+	// stop doubling before it overflows or exceeds max
+	if retry < 62 && b.Base <= b.Max>>uint(retry) {
+		ceiling = b.Base << uint(retry)
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
@@ -0,0 +1,21 @@
+package bri
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestFullJitterBackoff() {
+	backoff := NewFullJitterBackoff(10*time.Millisecond, 100*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		assert.True(bri.T(), backoff.Next(0) <= 10*time.Millisecond)
+		assert.True(bri.T(), backoff.Next(2) <= 40*time.Millisecond)
+		assert.True(bri.T(), backoff.Next(10) <= 100*time.Millisecond)
+		assert.True(bri.T(), backoff.Next(100) <= 100*time.Millisecond)
+		assert.True(bri.T(), backoff.Next(100) >= 0)
+	}
+
+	assert.Equal(bri.T(), time.Duration(0), NewFullJitterBackoff(0, 0).Next(1))
+}
@@ -0,0 +1,74 @@
+package bri
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchChargeItem defines a single charge of BatchCharge
+type BatchChargeItem struct {
+	// IdempotencyKey must be stable for the charge, e.g. derived from the order id, so re-running a failed batch
+	// doesn't charge the same order twice. It is required.
+	IdempotencyKey string
+	Request        PaymentChargeOTPRequest
+}
+
+// BatchChargeResult defines result of a single charge in BatchCharge
+type BatchChargeResult struct {
+	// IdempotencyKey is the key of the charge item, reuse it when retrying the charge
+	IdempotencyKey string
+	Response       PaymentChargeResponse
+	Err            error
+}
+
+// BatchCharge creates payment charge for every item using at most concurrency parallel calls.
+// Result is aligned with items index. Charge which is not started because ctx is done gets ctx error as its Err,
+// charge in flight is aborted when ctx is done.
+func (g *CoreGateway) BatchCharge(ctx context.Context, token string, items []BatchChargeItem, concurrency int) []BatchChargeResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]BatchChargeResult, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				item := items[i]
+				if item.IdempotencyKey == "" {
+					results[i] = BatchChargeResult{Err: ErrMissingIdempotencyKey}
+					continue
+				}
+
+				res, err := g.createPaymentCharge(ctx, token, item.IdempotencyKey, item.Request)
+				results[i] = BatchChargeResult{
+					IdempotencyKey: item.IdempotencyKey,
+					Response:       res,
+					Err:            err,
+				}
+			}
+		}()
+	}
+
+	for i := range items {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(items); j++ {
+				results[j].Err = ctx.Err()
+			}
+			close(jobs)
+			wg.Wait()
+			return results
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,112 @@
+package bri
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestBatchCharge() {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+
+		var req PaymentChargeOTPRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(bri.T(), "order-"+req.Body.Remarks, r.Header.Get("Idempotency-Key"))
+		json.NewEncoder(w).Encode(PaymentChargeResponse{
+			Body: PaymentChargeResponseData{
+				Status:  "0000",
+				Remarks: req.Body.Remarks,
+			},
+		})
+	}))
+	defer server.Close()
+
+	bri.client.DirectDebitBaseURL = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	items := make([]BatchChargeItem, 10)
+	for i := range items {
+		remarks := string(rune('a' + i))
+		items[i] = BatchChargeItem{
+			IdempotencyKey: "order-" + remarks,
+			Request:        NewPaymentChargeOTPRequest("card_.eyJ", NewMoney(1000000, CurrencyIDR), remarks),
+		}
+	}
+	items[9].IdempotencyKey = ""
+
+	results := coreGateway.BatchCharge(context.Background(), "token", items, 3)
+
+	assert.Equal(bri.T(), len(items), len(results))
+	for i, result := range results[:9] {
+		assert.Equal(bri.T(), nil, result.Err)
+		assert.Equal(bri.T(), items[i].Request.Body.Remarks, result.Response.Body.Remarks)
+		assert.Equal(bri.T(), items[i].IdempotencyKey, result.IdempotencyKey)
+	}
+	assert.Equal(bri.T(), ErrMissingIdempotencyKey, results[9].Err)
+	assert.True(bri.T(), atomic.LoadInt32(&maxInFlight) <= 3)
+
+	// re-running the batch sends the same idempotency keys
+	results = coreGateway.BatchCharge(context.Background(), "token", items[:1], 1)
+	assert.Equal(bri.T(), "order-a", results[0].IdempotencyKey)
+}
+
+func (bri *BriSanguTestSuite) TestBatchChargeCancelledContext() {
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := coreGateway.BatchCharge(ctx, "token", make([]BatchChargeItem, 5), 2)
+
+	assert.Equal(bri.T(), 5, len(results))
+	for _, result := range results {
+		assert.NotNil(bri.T(), result.Err)
+	}
+}
+
+func (bri *BriSanguTestSuite) TestBatchChargeAbortInFlight() {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// request context is cancelled on client disconnect only after the body is read
+		ioutil.ReadAll(r.Body)
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	bri.client.DirectDebitBaseURL = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	items := []BatchChargeItem{{
+		IdempotencyKey: "order-a",
+		Request:        NewPaymentChargeOTPRequest("card_.eyJ", NewMoney(1000000, CurrencyIDR), "a"),
+	}}
+	results := coreGateway.BatchCharge(ctx, "token", items, 1)
+	assert.Contains(bri.T(), results[0].Err.Error(), context.Canceled.Error())
+}
@@ -0,0 +1,84 @@
+package bri
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker short-circuits calls to BRI with ErrCircuitOpen after Threshold consecutive failures.
+// After Cooldown elapses, a single trial call is allowed; the breaker closes if it succeeds and opens again if it fails.
+// A failure is a network error or a 5xx response, a call cancelled by the caller is not a failure.
+// Threshold <= 0 disables the breaker.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker creates circuit breaker which opens after threshold consecutive failures for cooldown duration
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a call may be sent
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.Threshold <= 0 || b.failures < b.Threshold {
+		return true
+	}
+
+	if !b.trial && time.Since(b.openedAt) >= b.Cooldown {
+		b.trial = true
+		return true
+	}
+
+	return false
+}
+
+// success records successful call and closes the breaker
+func (b *CircuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+// failure records failed call and opens the breaker once threshold is reached
+func (b *CircuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.Threshold <= 0 {
+		return
+	}
+
+	b.failures++
+	b.trial = false
+	if b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// requestFailed records call which fails without response. ctxErr is error of the request context, a call cancelled or
+// timed out by the caller's context doesn't say anything about BRI, it only releases the trial so the next call can be tried.
+// Transport error, including Client.Timeout, is a failure.
+func (b *CircuitBreaker) requestFailed(ctxErr error) {
+	if ctxErr != nil {
+		b.mu.Lock()
+		b.trial = false
+		b.mu.Unlock()
+		return
+	}
+
+	b.failure()
+}
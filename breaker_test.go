@@ -0,0 +1,75 @@
+package bri
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestCircuitBreaker() {
+	breaker := NewCircuitBreaker(2, 50*time.Millisecond)
+
+	assert.Equal(bri.T(), true, breaker.allow())
+	breaker.failure()
+	assert.Equal(bri.T(), true, breaker.allow())
+	breaker.failure()
+
+	// open
+	assert.Equal(bri.T(), false, breaker.allow())
+
+	// half open, only one trial is allowed
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(bri.T(), true, breaker.allow())
+	assert.Equal(bri.T(), false, breaker.allow())
+
+	// failed trial opens the breaker again
+	breaker.failure()
+	assert.Equal(bri.T(), false, breaker.allow())
+
+	// successful trial closes the breaker
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(bri.T(), true, breaker.allow())
+	breaker.success()
+	assert.Equal(bri.T(), true, breaker.allow())
+	assert.Equal(bri.T(), true, breaker.allow())
+}
+
+func (bri *BriSanguTestSuite) TestCircuitBreakerOpenShortCircuitsCall() {
+	bri.client.CircuitBreaker = NewCircuitBreaker(1, time.Minute)
+	bri.client.CircuitBreaker.failure()
+
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	_, err := coreGateway.GetToken()
+
+	assert.True(bri.T(), errors.Is(err, ErrCircuitOpen))
+}
+
+func (bri *BriSanguTestSuite) TestCircuitBreakerCancelledTrial() {
+	breaker := NewCircuitBreaker(1, 50*time.Millisecond)
+	breaker.failure()
+
+	// trial cancelled or timed out by the caller's context doesn't open the breaker for another cooldown
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(bri.T(), true, breaker.allow())
+	breaker.requestFailed(context.Canceled)
+	assert.Equal(bri.T(), true, breaker.allow())
+	breaker.requestFailed(context.DeadlineExceeded)
+	assert.Equal(bri.T(), true, breaker.allow())
+
+	// transport error is a failure
+	breaker.requestFailed(nil)
+	assert.Equal(bri.T(), false, breaker.allow())
+}
+
+func (bri *BriSanguTestSuite) TestCircuitBreakerDisabled() {
+	breaker := NewCircuitBreaker(0, time.Minute)
+
+	breaker.failure()
+	breaker.requestFailed(nil)
+	assert.Equal(bri.T(), true, breaker.allow())
+	assert.Equal(bri.T(), true, breaker.allow())
+}
@@ -0,0 +1,48 @@
+package bri
+
+import (
+	"strings"
+)
+
+// maskedCardNumberLength is card number length assumed when only the last 4 digits are known
+const maskedCardNumberLength = 16
+
+// MaskCardNumber masks card number except its last 4 digits, e.g. "4111 1111 1111 1111" become "************1111".
+// Spaces and dashes are removed. Already masked number, e.g. "411111******1111", is masked further to its last 4 digits.
+func MaskCardNumber(cardNumber string) string {
+	cardNumber = strings.NewReplacer(" ", "", "-", "").Replace(cardNumber)
+	if len(cardNumber) <= 4 {
+		return cardNumber
+	}
+
+	return strings.Repeat("*", len(cardNumber)-4) + cardNumber[len(cardNumber)-4:]
+}
+
+// maskLast4 returns masked card number of a 16 digits card from its last 4 digits
+func maskLast4(last4 string) string {
+	if last4 == "" {
+		return ""
+	}
+
+	return MaskCardNumber(strings.Repeat("*", maskedCardNumberLength-len(last4)) + last4)
+}
+
+// CardNumberMasked returns masked card number showing only the last 4 digits
+func (d CardTokenOTPVerifyResponseData) CardNumberMasked() string {
+	return maskLast4(d.Last4)
+}
+
+// CardNumberMasked returns masked card number showing only the last 4 digits
+func (d CardTokenStatusResponseData) CardNumberMasked() string {
+	return maskLast4(d.Last4)
+}
+
+// CardNumberMasked returns masked card number showing only the last 4 digits.
+// MaskedCardPan is used if it is set, since it has the real card number length.
+func (d CardTokenData) CardNumberMasked() string {
+	if d.MaskedCardPan != "" {
+		return MaskCardNumber(d.MaskedCardPan)
+	}
+
+	return maskLast4(d.Last4)
+}
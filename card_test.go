@@ -0,0 +1,20 @@
+package bri
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestMaskCardNumber() {
+	assert.Equal(bri.T(), "************1111", MaskCardNumber("4111111111111111"))
+	assert.Equal(bri.T(), "************1111", MaskCardNumber("4111 1111-1111 1111"))
+	assert.Equal(bri.T(), "************1111", MaskCardNumber("411111******1111"))
+	assert.Equal(bri.T(), "1111", MaskCardNumber("1111"))
+	assert.Equal(bri.T(), "", MaskCardNumber(""))
+}
+
+func (bri *BriSanguTestSuite) TestCardNumberMasked() {
+	assert.Equal(bri.T(), "************1234", CardTokenOTPVerifyResponseData{Last4: "1234"}.CardNumberMasked())
+	assert.Equal(bri.T(), "", CardTokenStatusResponseData{}.CardNumberMasked())
+	assert.Equal(bri.T(), "*************5678", CardTokenData{MaskedCardPan: "526422123****5678", Last4: "5678"}.CardNumberMasked())
+	assert.Equal(bri.T(), "************5678", CardTokenData{Last4: "5678"}.CardNumberMasked())
+}
@@ -1,19 +1,48 @@
 package bri
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gojektech/heimdall"
 	"github.com/gojektech/heimdall/httpclient"
 )
 
+// Version is the library version, sent as part of default User-Agent
+const Version = "1.0.0"
+
+// Content types of BRI request body
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+	ContentTypeText = "text/plain"
+)
+
+// RequestIDHeader is header of client-side request id, see Client.RequestIDFunc
+const RequestIDHeader = "X-Request-Id"
+
+// DefaultUserAgent is User-Agent header sent on every request if Client.UserAgent is empty
+const DefaultUserAgent = "sangu-bri/" + Version
+
 type Client struct {
 	BaseUrl            string
 	DirectDebitBaseURL string
@@ -24,6 +53,126 @@ type Client struct {
 	Timeout            time.Duration
 	Logger             *log.Logger
 	IsProduction       bool
+
+	// TokenTimeout is timeout of access token request, so a hung auth endpoint fails fast. Zero means only Timeout applies.
+	TokenTimeout time.Duration
+
+	// FailoverBaseURLs are tried in order after BaseUrl if the request can't reach BRI (ErrConnection before the request is written).
+	// Timeout or connection reset after the request is written, and application error responses, are not retried on another host. Signature doesn't include the host,
+	// so the same signed request is sent to every host.
+	FailoverBaseURLs []string
+
+	// TokenStore stores access token used by CoreGateway.AccessToken, default is in-memory store of each gateway
+	TokenStore TokenStore
+
+	// PrivateKey is used to sign SNAP BI access token request
+	PrivateKey *rsa.PrivateKey
+	// PartnerID and ChannelID are sent as X-PARTNER-ID and CHANNEL-ID header of SNAP BI transactional request
+	PartnerID string
+	ChannelID string
+
+	// UseNumber decodes number in response into json.Number instead of float64 (for interface{} field, e.g. Metadata)
+	UseNumber bool
+	// DisallowUnknownFields makes decoding fail if response contains field which is not in response struct.
+	// Useful during development to catch BRI response schema changes.
+	DisallowUnknownFields bool
+
+	// RequestHook is called right before every request is sent, e.g. to add tracing header
+	RequestHook func(req *http.Request)
+	// ResponseHook is called after every response body is read, e.g. to audit BRI exchange. body is nil if the response is streamed.
+	ResponseHook func(res *http.Response, body []byte)
+
+	// MaxIdleConns, MaxIdleConnsPerHost and IdleConnTimeout configure keep-alive connection pool of the http transport
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// DialTimeout, TLSHandshakeTimeout and ResponseHeaderTimeout bound the phases of a single attempt on the http transport,
+	// while Timeout bounds the whole attempt including reading the body. ResponseHeaderTimeout is the wait for BRI response
+	// header after the request is written, set it to fail fast on a hung BRI endpoint while Timeout still tolerates a slow body,
+	// e.g. of a large report. Zero uses the default: 30s dial, 10s TLS handshake and no response header timeout.
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	// TLSConfig is used by the http transport, e.g. to set client certificate for BRI products which require mutual TLS
+	TLSConfig *tls.Config
+
+	// HTTP2 makes the http transport attempt HTTP/2 where BRI supports it, so concurrent requests are multiplexed
+	// over a single connection. Default is false, HTTP/1.1 is always used.
+	HTTP2 bool
+
+	// APIVersion pins BRI api version, sent as X-BRI-Api-Version header on every request if it is set
+	APIVersion string
+
+	// DefaultHeaders are sent on every request, e.g. partner or channel id required by some BRI endpoints.
+	// Headers of the operation take precedence, and signing headers (see protectedHeaders) are never overridden.
+	// BRI signature doesn't cover headers, so they don't change the signature.
+	DefaultHeaders map[string]string
+
+	// ReturnErrorsOnly stops logging errors which are returned to the caller, so the caller owns error reporting,
+	// e.g. through its own observability stack without double logging. Returned *Error has method, url and request id.
+	// Warnings, informational and debug logs still follow LogLevel.
+	ReturnErrorsOnly bool
+
+	// RequestIDFunc generates X-Request-Id header sent on every request, logged and set to Error.RequestID
+	// to correlate the call with BRI. Default is random UUID. Request id in headers of the call takes precedence.
+	RequestIDFunc func() string
+
+	// Language sets language of BRI error description, e.g. "id" or "en", sent as Accept-Language header if it is set
+	Language string
+
+	// MaxResponseBytes limits response body size, ErrResponseTooLarge is returned if it is exceeded. Default is 10MB.
+	MaxResponseBytes int64
+
+	// UserAgent overrides DefaultUserAgent
+	UserAgent string
+
+	// Backoff is retry backoff strategy, e.g. NewFullJitterBackoff. Default is constant backoff with small jitter.
+	Backoff heimdall.Backoff
+
+	// HeimdallOptions are applied after the default options when the http client is created, so they can override them,
+	// e.g. httpclient.WithRetryCount or httpclient.WithHTTPClient to wrap the doer with instrumentation.
+	// Replacing the http client drops keep-alive, TLSConfig and Timeout setting of this Client, and re-signing of retried request.
+	// Options can't be compared, so a Client with options doesn't reuse its http client and connection across calls.
+	HeimdallOptions []httpclient.Option
+
+	// RetryPredicate decides whether an attempt should be retried, overriding the default classification
+	// (see DefaultRetryPredicate), e.g. to retry a BRI response code which is known to be transient.
+	// resp is nil if err is not nil. Response body can be read by the predicate, it is rewound afterward.
+	// Func can't be compared, so a Client with predicate doesn't reuse its http client and connection across calls.
+	RetryPredicate func(req *http.Request, resp *http.Response, err error) bool
+
+	// ChargeCache returns the first response of CreatePaymentChargeOTP retried with the same idempotency key, nil means disabled
+	ChargeCache *ChargeCache
+
+	// CircuitBreaker stops sending request to BRI after consecutive failures, nil means disabled
+	CircuitBreaker *CircuitBreaker
+
+	// VerifyResponseSignature verifies BRI-Signature header of every response against its BRI-Timestamp header and body.
+	// ErrInvalidResponseSignature is returned on mismatch.
+	VerifyResponseSignature bool
+
+	// Now returns current time used for request timestamp, default is time.Now.
+	// Set it to a fixed time to get deterministic BRI-Timestamp and signature, e.g. in tests or to replay a request.
+	// BRI-Timestamp is formatted in UTC, SNAP BI X-TIMESTAMP in Asia/Jakarta.
+	Now func() time.Time
+
+	// MaxClockSkew is maximum allowed difference between local time and BRI server time (Date response header).
+	// If it is set, a warning is logged when a response exceeds it, see also CheckClockSkew.
+	MaxClockSkew time.Duration
+
+	// DryRun makes every call return *DryRunError holding the signed request instead of sending it to BRI
+	DryRun bool
+
+	// DebugWriter receives wire format dump of every request and response, e.g. to send to BRI support when debugging signature.
+	// Secret headers and fields (authorization, signature, client secret, access token, otp, card token, phone number) are redacted,
+	// card number is masked.
+	// Dump contains customer data, don't set it in production.
+	DebugWriter io.Writer
+
+	directDebitSandbox bool
+	httpClient         *httpClientCache
 }
 
 // NewClient : this function will always be called when the library is in use
@@ -38,61 +187,430 @@ func NewClient() Client {
 		Timeout:      3 * time.Minute,
 		Logger:       log.New(os.Stderr, "", log.LstdFlags),
 		IsProduction: false,
+
+		MaxIdleConns:        defHTTPMaxIdleConns,
+		MaxIdleConnsPerHost: defHTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     defHTTPIdleConnTimeout,
+		MaxResponseBytes:    defMaxResponseBytes,
+		httpClient:          &httpClientCache{},
+	}
+}
+
+// Environment variables read by NewClientFromEnv
+const (
+	EnvClientID           = "BRI_CLIENT_ID"
+	EnvClientSecret       = "BRI_CLIENT_SECRET"
+	EnvAPIKey             = "BRI_API_KEY"
+	EnvBaseURL            = "BRI_BASE_URL"
+	EnvDirectDebitBaseURL = "BRI_DIRECT_DEBIT_BASE_URL"
+	EnvPrivateKey         = "BRI_PRIVATE_KEY"
+	EnvPrivateKeyPath     = "BRI_PRIVATE_KEY_PATH"
+)
+
+// NewClientFromEnv creates client using NewClient and populates its credential from environment variables.
+// EnvClientID and EnvClientSecret are required, ErrMissingEnv listing the missing variables is returned if any of them is empty.
+// EnvAPIKey (only needed for non production direct debit) and base urls are optional.
+// SNAP BI private key is loaded using LoadPrivateKeyFromEnv if EnvPrivateKey or EnvPrivateKeyPath is set.
+func NewClientFromEnv() (Client, error) {
+	c := NewClient()
+	c.ClientId = os.Getenv(EnvClientID)
+	c.ClientSecret = os.Getenv(EnvClientSecret)
+	c.APIKey = os.Getenv(EnvAPIKey)
+	c.BaseUrl = os.Getenv(EnvBaseURL)
+	c.DirectDebitBaseURL = os.Getenv(EnvDirectDebitBaseURL)
+
+	var missing []string
+	if c.ClientId == "" {
+		missing = append(missing, EnvClientID)
+	}
+	if c.ClientSecret == "" {
+		missing = append(missing, EnvClientSecret)
 	}
+
+	if len(missing) > 0 {
+		return c, fmt.Errorf("%w: %s", ErrMissingEnv, strings.Join(missing, ", "))
+	}
+
+	if os.Getenv(EnvPrivateKey) != "" || os.Getenv(EnvPrivateKeyPath) != "" {
+		privateKey, err := LoadPrivateKeyFromEnv()
+		if err != nil {
+			return c, err
+		}
+		c.PrivateKey = privateKey
+	}
+
+	return c, nil
 }
 
 // ===================== HTTP CLIENT ================================================
 var defHTTPBackoffInterval = 2 * time.Millisecond
 var defHTTPMaxJitterInterval = 5 * time.Millisecond
 var defHTTPRetryCount = 3
+var defHTTPMaxIdleConns = 100
+var defHTTPMaxIdleConnsPerHost = 10
+var defHTTPIdleConnTimeout = 90 * time.Second
+var defHTTPDialTimeout = 30 * time.Second
+var defHTTPTLSHandshakeTimeout = 10 * time.Second
+
+// productionHosts and sandboxHosts are known BRI hosts, used to catch client environment misconfiguration
+var productionHosts = []string{"partner.api.bri.co.id"}
+var sandboxHosts = []string{"sandbox.partner.api.bri.co.id"}
+
+// defMaxClockSkew is maximum clock skew used by CheckClockSkew if Client.MaxClockSkew is not set
+var defMaxClockSkew = 1 * time.Minute
+
+// protectedHeaders can't be set by Client.DefaultHeaders
+var protectedHeaders = map[string]bool{
+	"Authorization":   true,
+	"Bri-Timestamp":   true,
+	"Bri-Signature":   true,
+	"X-Bri-Signature": true,
+	"X-Bri-Api-Key":   true,
+	"Content-Type":    true,
+}
+
+// defMaxResponseBytes is maximum response body size if Client.MaxResponseBytes is not set
+var defMaxResponseBytes int64 = 10 << 20
+
+// bodySnippetLength is maximum length of response body put in error message
+var bodySnippetLength = 200
+
+// httpClientCache holds http clients which are shared by copies of the same Client.
+// Copies with the same http setting share a client, a copy with different setting gets its own client.
+type httpClientCache struct {
+	mu      sync.Mutex
+	clients map[httpClientKey]*cachedHTTPClient
+}
+
+type cachedHTTPClient struct {
+	client    *httpclient.Client
+	transport *http.Transport
+}
+
+// httpClientKey is the comparable Client setting which is used to create http client, Backoff is compared by its value
+type httpClientKey struct {
+	timeout               time.Duration
+	maxIdleConns          int
+	maxIdleConnsPerHost   int
+	idleConnTimeout       time.Duration
+	dialTimeout           time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	tlsConfig             *tls.Config
+	http2                 bool
+	backoff               heimdall.Backoff
+}
+
+// httpClientKey returns key of the http setting, ok is false if the setting can't be compared:
+// Backoff of non comparable type, or func setting (RetryPredicate, HeimdallOptions) since closures of the same code
+// can't be told apart, e.g. httpclient.WithRetryCount(1) and httpclient.WithRetryCount(5).
+func (c *Client) httpClientKey() (key httpClientKey, ok bool) {
+	if c.Backoff != nil && !reflect.TypeOf(c.Backoff).Comparable() {
+		return key, false
+	}
+
+	if c.RetryPredicate != nil || len(c.HeimdallOptions) > 0 {
+		return key, false
+	}
+
+	key = httpClientKey{
+		timeout:               c.Timeout,
+		maxIdleConns:          c.MaxIdleConns,
+		maxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		idleConnTimeout:       c.IdleConnTimeout,
+		dialTimeout:           c.DialTimeout,
+		tlsHandshakeTimeout:   c.TLSHandshakeTimeout,
+		responseHeaderTimeout: c.ResponseHeaderTimeout,
+		tlsConfig:             c.TLSConfig,
+		http2:                 c.HTTP2,
+		backoff:               c.Backoff,
+	}
+
+	return key, true
+}
+
+// keepAliveDoer re-enables connection reuse of shared transport, since heimdall marks every request to be closed.
+// Transport which is not shared keeps the connection closed, otherwise its idle connection would be left behind.
+type keepAliveDoer struct {
+	client    *http.Client
+	keepAlive bool
+}
+
+func (d *keepAliveDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.keepAlive {
+		req.Close = false
+	}
+	// keepAliveDoer is called on every attempt, including retries of heimdall and retryDoer
+	resignRetry(req)
+	return d.client.Do(req)
+}
+
+// DefaultRetryPredicate is retry classification used if Client.RetryPredicate is not set:
+// connection error and 5xx response are retried.
+func DefaultRetryPredicate(req *http.Request, resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryDoer retries request as decided by Client.RetryPredicate, heimdall retry is disabled when it is used
+type retryDoer struct {
+	doer       heimdall.Doer
+	retrier    heimdall.Retriable
+	retryCount int
+	predicate  func(req *http.Request, resp *http.Response, err error) bool
+}
+
+func (d *retryDoer) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; ; i++ {
+		if reqBody != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+		}
+
+		res, err := d.doer.Do(req)
+		if i >= d.retryCount {
+			return res, err
+		}
+
+		// buffer response body, so the predicate can read it and it is still readable by caller
+		var resBody []byte
+		if res != nil {
+			resBody, err = ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+		}
 
-// getHTTPClient will get heimdall http client
+		if !d.predicate(req, res, err) {
+			if res != nil {
+				res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+			}
+			return res, err
+		}
+
+		time.Sleep(d.retrier.NextInterval(i))
+	}
+}
+
+// getHTTPClient will get heimdall http client. The client is created on first call of each http setting and reused afterward,
+// so copies of a Client share keep-alive connections unless their http setting differs.
+// Client which is not created using NewClient, or whose setting can't be compared, gets a new http client on every call
+// which closes its connection after the request.
 func (c *Client) getHTTPClient() *httpclient.Client {
-	backoff := heimdall.NewConstantBackoff(defHTTPBackoffInterval, defHTTPMaxJitterInterval)
+	key, ok := c.httpClientKey()
+	if c.httpClient == nil || !ok {
+		return c.newHTTPClient(c.newTransport(), false)
+	}
+
+	c.httpClient.mu.Lock()
+	defer c.httpClient.mu.Unlock()
+
+	if cached, ok := c.httpClient.clients[key]; ok {
+		return cached.client
+	}
+
+	if c.httpClient.clients == nil {
+		c.httpClient.clients = make(map[httpClientKey]*cachedHTTPClient)
+	}
+
+	transport := c.newTransport()
+	cached := &cachedHTTPClient{
+		client:    c.newHTTPClient(transport, true),
+		transport: transport,
+	}
+	c.httpClient.clients[key] = cached
+
+	return cached.client
+}
+
+// Close closes idle keep-alive connections of the shared http clients.
+// Client can still be used after Close, new connections are opened as needed.
+func (c *Client) Close() error {
+	if c.httpClient == nil {
+		return nil
+	}
+
+	c.httpClient.mu.Lock()
+	defer c.httpClient.mu.Unlock()
+
+	for _, cached := range c.httpClient.clients {
+		cached.transport.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+// newHTTPClient will create heimdall http client, keepAlive is set if the transport is shared by later calls
+func (c *Client) newHTTPClient(transport *http.Transport, keepAlive bool) *httpclient.Client {
+	backoff := c.Backoff
+	if backoff == nil {
+		backoff = heimdall.NewConstantBackoff(defHTTPBackoffInterval, defHTTPMaxJitterInterval)
+	}
 	retrier := heimdall.NewRetrier(backoff)
 
-	return httpclient.NewClient(
-		httpclient.WithHTTPTimeout(c.Timeout),
+	var doer heimdall.Doer = &keepAliveDoer{
+		client: &http.Client{
+			Timeout:   c.Timeout,
+			Transport: transport,
+		},
+		keepAlive: keepAlive,
+	}
+
+	retryCount := defHTTPRetryCount
+	if c.RetryPredicate != nil {
+		doer = &retryDoer{
+			doer:       doer,
+			retrier:    retrier,
+			retryCount: retryCount,
+			predicate:  c.RetryPredicate,
+		}
+		retryCount = 0
+	}
+
+	opts := []httpclient.Option{
+		httpclient.WithHTTPClient(doer),
 		httpclient.WithRetrier(retrier),
-		httpclient.WithRetryCount(defHTTPRetryCount),
-	)
+		httpclient.WithRetryCount(retryCount),
+	}
+
+	return httpclient.NewClient(append(opts, c.HeimdallOptions...)...)
+}
+
+// newTransport will create http transport with keep-alive enabled
+func (c *Client) newTransport() *http.Transport {
+	dialTimeout := c.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = defHTTPDialTimeout
+	}
+
+	tlsHandshakeTimeout := c.TLSHandshakeTimeout
+	if tlsHandshakeTimeout <= 0 {
+		tlsHandshakeTimeout = defHTTPTLSHandshakeTimeout
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		TLSClientConfig:       c.TLSConfig,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     c.HTTP2,
+	}
+
+	if !c.HTTP2 {
+		// non nil empty map disables HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport
 }
 
-// DirectDebitHostUseSandboxPrefix used to modify direct debit staging url to use /sandbox/* path due to different host
+// DirectDebitHostUseSandboxPrefix used to modify direct debit staging url to use /sandbox/* path due to different host.
+// The setting only applies to this client, so clients with different environment can be used in the same process.
 func (c *Client) DirectDebitHostUseSandboxPrefix(use bool) {
-	if use {
-		urlCreateCardTokenOTP = "/sandbox/v1/directdebit/tokens"                   // POST
-		urlCreateCardTokenOTPVerify = "/sandbox/v1/directdebit/tokens"             // PATCH
-		urlDeleteCardToken = "/sandbox/v1/directdebit/tokens"                      // DELETE
-		urlCreatePaymentChargeOTP = "/sandbox/v1/directdebit/charges"              // POST
-		urlCreatePaymentChargeOTPVerify = "/sandbox/v1/directdebit/charges/verify" // POST
-		urlChargeDetail = "/sandbox/v1/directdebit/charges/inquiry"                // POST
-		urlRefundDirectDebit = "/sandbox/v1/directdebit/refunds"                   // POST
-	} else {
-		// production path user "rt-" prefix
-		urlCreateCardTokenOTP = "/v1/rt-directdebit/tokens"                   // POST
-		urlCreateCardTokenOTPVerify = "/v1/rt-directdebit/tokens"             // PATCH
-		urlDeleteCardToken = "/v1/rt-directdebit/tokens"                      // DELETE
-		urlCreatePaymentChargeOTP = "/v1/rt-directdebit/charges"              // POST
-		urlCreatePaymentChargeOTPVerify = "/v1/rt-directdebit/charges/verify" // POST
-		urlChargeDetail = "/v1/rt-directdebit/charges/inquiry"                // POST
-		urlRefundDirectDebit = "/v1/rt-directdebit/refunds"                   // POST
+	c.directDebitSandbox = use
+}
+
+// directDebitPath returns direct debit url path based on client environment.
+// Production path use "rt-" prefix, e.g. /v1/rt-directdebit/tokens become /sandbox/v1/directdebit/tokens on sandbox.
+func (c *Client) directDebitPath(path string) string {
+	if c.directDebitSandbox {
+		return strings.Replace(path, "/v1/rt-directdebit", "/sandbox/v1/directdebit", 1)
 	}
+
+	return path
+}
+
+// timestamp returns current time of the client in the given format
+func (c *Client) timestamp(format string) string {
+	if c.Now == nil {
+		return getTimestamp(format)
+	}
+
+	return c.Now().UTC().Format(format)
+}
+
+// snapTimestamp returns current time of Client.Now in Asia/Jakarta formatted as SNAP_TIME_FORMAT
+func (c *Client) snapTimestamp() string {
+	now := time.Now()
+	if c.Now != nil {
+		now = c.Now()
+	}
+
+	return now.In(snapLocation).Format(SNAP_TIME_FORMAT)
+}
+
+// logPrintln prints to Logger when LogLevel is at least level. Logger may be nil to disable logging,
+// errors are still returned to the caller.
+func (c *Client) logPrintln(level int, v ...interface{}) {
+	if c.Logger == nil || c.LogLevel < level {
+		return
+	}
+
+	c.Logger.Println(v...)
+}
+
+// logError logs error which is returned to the caller, unless ReturnErrorsOnly is set
+func (c *Client) logError(v ...interface{}) {
+	if c.ReturnErrorsOnly {
+		return
+	}
+
+	c.logPrintln(1, v...)
+}
+
+// signature generates BRI-Signature using client secret. String to sign is logged on debug log level.
+func (c *Client) signature(path, method, token, timestamp, body string) string {
+	c.logPrintln(3, "BRI string to sign: ", StringToSign(path, method, token, timestamp, body))
+
+	return generateSignature(path, method, token, timestamp, body, c.ClientSecret)
 }
 
 // NewRequest : send new request
 func (c *Client) NewRequest(method string, fullPath string, headers map[string]string, body io.Reader) (*http.Request, error) {
-	logLevel := c.LogLevel
-	logger := c.Logger
-
 	req, err := http.NewRequest(method, fullPath, body)
 	if err != nil {
-		if logLevel > 0 {
-			logger.Println("Request creation failed: ", err)
-		}
+		c.logError("Request creation failed: ", err)
 		return nil, err
 	}
 
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", ContentTypeJSON)
+
+	if c.APIVersion != "" {
+		req.Header.Set("X-BRI-Api-Version", c.APIVersion)
+	}
+
+	if c.Language != "" {
+		req.Header.Set("Accept-Language", c.Language)
+	}
+
+	for k, vv := range c.DefaultHeaders {
+		if !protectedHeaders[http.CanonicalHeaderKey(k)] {
+			req.Header.Set(k, vv)
+		}
+	}
+
+	// headers of the operation take precedence over the default headers
 	if headers != nil {
 		for k, vv := range headers {
 			req.Header.Set(k, vv)
@@ -104,62 +622,94 @@ func (c *Client) NewRequest(method string, fullPath string, headers map[string]s
 
 // ExecuteRequest : execute request
 func (c *Client) ExecuteRequest(req *http.Request, v interface{}, vErr interface{}) error {
-	logLevel := c.LogLevel
-	logger := c.Logger
-
-	if logLevel > 1 {
-		logger.Println("Request ", req.Method, ": ", req.URL.Host, req.URL.Path)
-	}
-
-	start := time.Now()
-	res, err := c.getHTTPClient().Do(req)
+	res, duration, reqDump, err := c.send(req)
 	if err != nil {
-		if logLevel > 0 {
-			logger.Println("Cannot send request: ", err)
-		}
 		return err
 	}
 	defer res.Body.Close()
 
-	if logLevel > 2 {
-		logger.Println("Completed in ", time.Since(start))
+	// streamed response can't be verified before it is decoded, so it is read whole if VerifyResponseSignature is set
+	if s, ok := v.(streamDecoder); ok && !c.VerifyResponseSignature {
+		c.debugExchange(reqDump, res, nil, nil)
+		return c.receiveStream(res, duration, s)
 	}
 
+	maxResponseBytes := c.MaxResponseBytes
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = defMaxResponseBytes
+	}
+
+	resBody, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBytes+1))
 	if err != nil {
-		if logLevel > 0 {
-			logger.Println("Request failed: ", err)
-		}
+		c.logError("Cannot read response body: ", err)
 		return err
 	}
 
-	resBody, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		if logLevel > 0 {
-			logger.Println("Cannot read response body: ", err)
+	c.debugExchange(reqDump, res, resBody, nil)
+
+	if int64(len(resBody)) > maxResponseBytes {
+		c.logError("Cannot read response body: ", ErrResponseTooLarge)
+		return ErrResponseTooLarge
+	}
+
+	c.receive(res, resBody, duration, v, vErr)
+
+	if c.VerifyResponseSignature {
+		if err = c.verifyResponseSignature(res.Header, resBody); err != nil {
+			c.logError("Response verification failed: ", err)
+			return err
 		}
-		return err
 	}
 
-	if logLevel > 2 {
-		logger.Println("BRI HTTP status response: ", res.StatusCode)
-		logger.Println("BRI body response: ", string(resBody))
+	c.logPrintln(3, "BRI HTTP status response: ", res.StatusCode)
+	c.logPrintln(3, "BRI body response: ", string(resBody))
+
+	// 401 and 403 are auth errors whatever the body is, it is still decoded if possible so BRI error detail is available
+	if authErr := c.authError(res.StatusCode); authErr != nil {
+		target := v
+		if vErr != nil {
+			target = vErr
+		}
+		if target != nil && len(bytes.TrimSpace(resBody)) > 0 {
+			c.decode(resBody, target)
+		}
+
+		return fmt.Errorf("%w: %s", authErr, bodySnippet(resBody))
 	}
 
-	if res.StatusCode == 404 {
-		return errors.New("invalid url")
+	// BRI uses 404 for resource not found too, only treat it as invalid url if the body can't be decoded
+	if res.StatusCode == http.StatusNotFound {
+		target := v
+		if vErr != nil {
+			target = vErr
+		}
+
+		if target == nil || len(bytes.TrimSpace(resBody)) == 0 || c.decode(resBody, target) != nil {
+			return ErrInvalidURL
+		}
+
+		return fmt.Errorf("%w: %s", ErrNotFound, bodySnippet(resBody))
 	}
 
 	if res.StatusCode == 204 {
 		return errors.New("204: empty response")
 	}
 
-	if v != nil {
-		if err = json.Unmarshal(resBody, v); err != nil {
-			if vErr != nil {
-				err = json.Unmarshal(resBody, &vErr)
-			}
+	// BRI may return html page instead of json, e.g. during maintenance
+	if isHTMLResponse(res.Header, resBody) {
+		return htmlResponseError(res.StatusCode, bodySnippet(resBody))
+	}
+
+	// if vErr is given, non 2xx response is decoded only into vErr, so v is never populated from an error body
+	target := v
+	if vErr != nil && (res.StatusCode < 200 || res.StatusCode > 299) {
+		target = vErr
+	}
 
-			if res.StatusCode == http.StatusOK {
+	if target != nil {
+		if err = c.decode(resBody, target); err != nil {
+			// error of stream decoder may come from its callback, it is returned as is
+			if _, ok := target.(streamDecoder); !ok && res.StatusCode == http.StatusOK {
 				return ErrPendingTransaction
 			}
 			return err
@@ -169,17 +719,335 @@ func (c *Client) ExecuteRequest(req *http.Request, v interface{}, vErr interface
 	return nil
 }
 
+// send sends req, it returns *notSentError if the request is not written to BRI.
+// reqDump is the request dump for DebugWriter, nil if it is not set.
+func (c *Client) send(req *http.Request) (res *http.Response, duration time.Duration, reqDump []byte, err error) {
+	c.logPrintln(2, "Request ", req.Method, ": ", req.URL.Host, req.URL.Path, " ", RequestIDHeader, ": ", req.Header.Get(RequestIDHeader))
+
+	if c.CircuitBreaker != nil && !c.CircuitBreaker.allow() {
+		c.logError("Request is not sent: ", ErrCircuitOpen)
+		return nil, 0, nil, &notSentError{Err: ErrCircuitOpen}
+	}
+
+	if c.RequestHook != nil {
+		c.RequestHook(req)
+	}
+
+	reqDump = c.debugRequest(req)
+
+	// written records whether any attempt has written the request to the connection
+	var written int32
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			atomic.StoreInt32(&written, 1)
+		},
+	}))
+
+	start := time.Now()
+	res, err = c.getHTTPClient().Do(req)
+	if err != nil {
+		if c.CircuitBreaker != nil {
+			c.CircuitBreaker.requestFailed(req.Context().Err())
+		}
+		c.debugExchange(reqDump, nil, nil, err)
+		c.logError("Cannot send request: ", err)
+		err = fmt.Errorf("%w: %v", ErrConnection, err)
+		// request never reached BRI, e.g. dns failure or connection refused
+		if atomic.LoadInt32(&written) == 0 {
+			return nil, 0, reqDump, &notSentError{Err: err}
+		}
+		return nil, 0, reqDump, err
+	}
+
+	if c.CircuitBreaker != nil {
+		if res.StatusCode >= http.StatusInternalServerError {
+			c.CircuitBreaker.failure()
+		} else {
+			c.CircuitBreaker.success()
+		}
+	}
+
+	duration = time.Since(start)
+	c.logPrintln(3, "Completed in ", duration)
+
+	return res, duration, reqDump, nil
+}
+
+// receive runs the steps shared by every response before it is decoded: ResponseHook, clock skew check and RawResponse.
+// body is nil if the response is streamed.
+func (c *Client) receive(res *http.Response, body []byte, duration time.Duration, v interface{}, vErr interface{}) {
+	if c.ResponseHook != nil {
+		c.ResponseHook(res, body)
+	}
+
+	if c.MaxClockSkew > 0 {
+		if skew, ok := c.clockSkew(res.Header); ok && absDuration(skew) > c.MaxClockSkew {
+			c.logPrintln(1, "Clock skew with BRI server exceeds MaxClockSkew, request signature may be rejected: ", skew)
+		}
+	}
+
+	raw := &RawResponse{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Duration:   duration,
+	}
+	if r, ok := v.(rawResponseSetter); ok {
+		r.setRawResponse(raw)
+	}
+	if r, ok := vErr.(rawResponseSetter); ok {
+		r.setRawResponse(raw)
+	}
+}
+
+// receiveStream decodes response body as it is read, see streamDecoder
+func (c *Client) receiveStream(res *http.Response, duration time.Duration, v streamDecoder) error {
+	c.receive(res, nil, duration, v, nil)
+
+	c.logPrintln(3, "BRI HTTP status response: ", res.StatusCode)
+
+	if authErr := c.authError(res.StatusCode); authErr != nil {
+		snippet, _ := ioutil.ReadAll(io.LimitReader(res.Body, int64(bodySnippetLength)))
+		return fmt.Errorf("%w: %s", authErr, bodySnippet(snippet))
+	}
+
+	if isHTMLResponse(res.Header, nil) {
+		return htmlResponseError(res.StatusCode, "")
+	}
+
+	dec := json.NewDecoder(res.Body)
+	if c.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := v.decodeStream(dec); err != nil {
+		if res.StatusCode == http.StatusNotFound {
+			return ErrInvalidURL
+		}
+		c.logError("Cannot decode response body: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// authError returns ErrUnauthorized for 401 and ErrForbidden for 403 response, otherwise nil
+func (c *Client) authError(statusCode int) error {
+	var err error
+	switch statusCode {
+	case http.StatusUnauthorized:
+		err = ErrUnauthorized
+	case http.StatusForbidden:
+		err = ErrForbidden
+	default:
+		return nil
+	}
+
+	c.logError("Request is rejected: ", err)
+	return err
+}
+
+// htmlResponseError returns error of html response, e.g. BRI maintenance page
+func htmlResponseError(statusCode int, snippet string) error {
+	if statusCode == http.StatusOK {
+		return fmt.Errorf("%w: %s", ErrPendingTransaction, snippet)
+	}
+
+	return fmt.Errorf("%w: http status %d, likely BRI maintenance: %s", ErrUnexpectedResponse, statusCode, snippet)
+}
+
+// checkEnvironment returns ErrEnvironmentMismatch if url is a known BRI production host but IsProduction is false, or the other way around
+func (c *Client) checkEnvironment(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// invalid url is reported by NewRequest
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, h := range productionHosts {
+		if host == h && !c.IsProduction {
+			return fmt.Errorf("%w: %s is production host but IsProduction is false", ErrEnvironmentMismatch, host)
+		}
+	}
+
+	for _, h := range sandboxHosts {
+		if host == h && c.IsProduction {
+			return fmt.Errorf("%w: %s is sandbox host but IsProduction is true", ErrEnvironmentMismatch, host)
+		}
+	}
+
+	return nil
+}
+
+// CheckClockSkew compares local time with Date header of BRI response to a HEAD request to BaseUrl.
+// It returns the skew (BRI time minus local time) and ErrClockSkew if it exceeds MaxClockSkew (default 1 minute).
+// Call it on startup to detect host clock drift, which makes BRI reject request signature.
+func (c *Client) CheckClockSkew() (skew time.Duration, err error) {
+	if c.BaseUrl == "" {
+		return 0, ErrMissingBaseURL
+	}
+
+	req, err := c.NewRequest(http.MethodHead, c.BaseUrl, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := c.getHTTPClient().Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrConnection, err)
+	}
+	res.Body.Close()
+
+	skew, ok := c.clockSkew(res.Header)
+	if !ok {
+		return 0, fmt.Errorf("%w: missing or invalid Date header", ErrUnexpectedResponse)
+	}
+
+	maxSkew := c.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = defMaxClockSkew
+	}
+
+	if absDuration(skew) > maxSkew {
+		return skew, fmt.Errorf("%w: %v", ErrClockSkew, skew)
+	}
+
+	return skew, nil
+}
+
+// clockSkew returns difference between Date response header and local time
+func (c *Client) clockSkew(header http.Header) (time.Duration, bool) {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+
+	now := time.Now()
+	if c.Now != nil {
+		now = c.Now()
+	}
+
+	return date.Sub(now), true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// isHTMLResponse reports whether response is html instead of json
+func isHTMLResponse(header http.Header, body []byte) bool {
+	return strings.Contains(header.Get("Content-Type"), "text/html") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
+
+// bodySnippet returns beginning of response body to be put in error message
+func bodySnippet(body []byte) string {
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > bodySnippetLength {
+		snippet = snippet[:bodySnippetLength] + "..."
+	}
+
+	return snippet
+}
+
+// verifyResponseSignature compares response signature header with signature computed using client secret
+func (c *Client) verifyResponseSignature(header http.Header, body []byte) error {
+	signature := header.Get("BRI-Signature")
+	if signature == "" {
+		signature = header.Get("X-BRI-Signature")
+	}
+
+	expected := generateResponseSignature(header.Get("BRI-Timestamp"), string(body), c.ClientSecret)
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidResponseSignature
+	}
+
+	return nil
+}
+
+// decode decodes response body into v based on client decoding setting
+func (c *Client) decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.UseNumber {
+		dec.UseNumber()
+	}
+
+	if s, ok := v.(streamDecoder); ok {
+		return s.decodeStream(dec)
+	}
+	if c.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if s, ok := v.(extraSetter); ok {
+		s.setExtra(unknownFields(data, reflect.TypeOf(v)))
+	}
+
+	return nil
+}
+
 // Call the BRI API at specific `path` using the specified HTTP `method`. The result will be
 // given to `v` if there is no error. If any error occurred, the return of this function is the error
 // itself, otherwise nil.
+//
+// Returned error is *Error which wraps the underlying error with the http method and url.
 func (c *Client) Call(method, path string, header map[string]string, body io.Reader, v interface{}, vErr interface{}) error {
+	return c.call(context.Background(), method, path, header, body, v, vErr)
+}
+
+// newRequestID returns request id generated by RequestIDFunc, or random UUID if it is not set
+func (c *Client) newRequestID() string {
+	if c.RequestIDFunc != nil {
+		return c.RequestIDFunc()
+	}
+
+	return newRequestID()
+}
+
+// tokenContext returns context of access token request derived from parent, bounded by TokenTimeout if it is set
+func (c *Client) tokenContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.TokenTimeout > 0 {
+		return context.WithTimeout(parent, c.TokenTimeout)
+	}
+
+	return context.WithCancel(parent)
+}
+
+// call is Call bound to ctx, e.g. to apply TokenTimeout
+func (c *Client) call(ctx context.Context, method, path string, header map[string]string, body io.Reader, v interface{}, vErr interface{}) error {
+	if err := c.checkEnvironment(path); err != nil {
+		return &Error{Method: method, URL: path, Err: err, RetrySafe: true}
+	}
+
 	req, err := c.NewRequest(method, path, header, body)
 
 	if err != nil {
-		return err
+		return &Error{Method: method, URL: path, Err: err, RetrySafe: true}
+	}
+	req = req.WithContext(ctx)
+
+	requestID := req.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = c.newRequestID()
+		req.Header.Set(RequestIDHeader, requestID)
 	}
 
-	return c.ExecuteRequest(req, v, vErr)
+	if c.DryRun {
+		return &Error{Method: method, URL: path, Err: &DryRunError{Request: req}, RequestID: requestID, RetrySafe: true}
+	}
+
+	if err = c.ExecuteRequest(req, v, vErr); err != nil {
+		var notSent *notSentError
+		return &Error{Method: method, URL: path, Err: err, RequestID: requestID, RetrySafe: errors.As(err, &notSent)}
+	}
+
+	return nil
 }
 
 // ===================== END HTTP CLIENT ================================================
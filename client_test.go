@@ -0,0 +1,739 @@
+package bri
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gojektech/heimdall/httpclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestDecodeUseNumber() {
+	bri.client.UseNumber = true
+
+	var resp CardTokenOTPVerifyResponse
+	err := bri.client.decode([]byte(`{"body":{"status":"0000","metadata":{"amount":10000.00}}}`), &resp)
+
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), json.Number("10000.00"), resp.Body.Metadata["amount"])
+}
+
+func (bri *BriSanguTestSuite) TestDecodeDisallowUnknownFields() {
+	var resp DeleteCardTokenResponse
+	err := bri.client.decode([]byte(`{"body":{"status":"0000","new_field":"x"}}`), &resp)
+	assert.Equal(bri.T(), nil, err)
+
+	bri.client.DisallowUnknownFields = true
+	err = bri.client.decode([]byte(`{"body":{"status":"0000","new_field":"x"}}`), &resp)
+	assert.NotNil(bri.T(), err)
+}
+
+func (bri *BriSanguTestSuite) TestDecodeExtra() {
+	var resp PaymentChargeResponse
+	err := bri.client.decode([]byte(`{"body":{"payment_id":"p","new_field":"x"},"trace_id":"t","error":{"code":"","hint":1},"STATUS_CODE":200}`), &resp)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "p", resp.Body.PaymentID)
+	assert.Equal(bri.T(), map[string]json.RawMessage{
+		"body.new_field": json.RawMessage(`"x"`),
+		"trace_id":       json.RawMessage(`"t"`),
+		"error.hint":     json.RawMessage(`1`),
+	}, resp.Extra())
+
+	err = bri.client.decode([]byte(`{"body":{"payment_id":"p"}}`), &resp)
+	assert.Equal(bri.T(), nil, err)
+	assert.Nil(bri.T(), resp.Extra())
+}
+
+func (bri *BriSanguTestSuite) TestDryRun() {
+	bri.client.DryRun = true
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	req := CreateVaRequest{
+		InstitutionCode: "J104408",
+		BrivaNo:         "77777",
+		CustCode:        "123123",
+	}
+	_, err := coreGateway.CreateVA("token", req)
+
+	var dryRun *DryRunError
+	assert.True(bri.T(), errors.As(err, &dryRun))
+	assert.Equal(bri.T(), "POST", dryRun.Request.Method)
+	assert.Equal(bri.T(), bri.client.BaseUrl+VA_PATH, dryRun.Request.URL.String())
+	assert.Equal(bri.T(), "Bearer token", dryRun.Request.Header.Get("Authorization"))
+	assert.NotEqual(bri.T(), "", dryRun.Request.Header.Get("BRI-Signature"))
+}
+
+func (bri *BriSanguTestSuite) TestNewRequestUserAgent() {
+	req, err := bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), DefaultUserAgent, req.Header.Get("User-Agent"))
+
+	bri.client.UserAgent = "my-app/2.0"
+	req, err = bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "my-app/2.0", req.Header.Get("User-Agent"))
+}
+
+func (bri *BriSanguTestSuite) TestNewRequestAccept() {
+	req, err := bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), ContentTypeJSON, req.Header.Get("Accept"))
+
+	req, err = bri.client.NewRequest("GET", bri.client.BaseUrl, map[string]string{"Accept": "text/plain"}, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "text/plain", req.Header.Get("Accept"))
+}
+
+func (bri *BriSanguTestSuite) TestNewRequestDefaultHeaders() {
+	bri.client.DefaultHeaders = map[string]string{
+		"X-Partner-Id":  "partner",
+		"X-Channel-Id":  "channel",
+		"BRI-Signature": "default",
+		"Authorization": "default",
+	}
+
+	headers := map[string]string{
+		"BRI-Signature": "signature",
+		"X-Channel-Id":  "operation",
+	}
+	req, err := bri.client.NewRequest("POST", bri.client.BaseUrl, headers, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "partner", req.Header.Get("X-Partner-Id"))
+	assert.Equal(bri.T(), "operation", req.Header.Get("X-Channel-Id"))
+	assert.Equal(bri.T(), "signature", req.Header.Get("BRI-Signature"))
+	assert.Equal(bri.T(), "", req.Header.Get("Authorization"))
+}
+
+func (bri *BriSanguTestSuite) TestNewRequestAPIVersion() {
+	req, err := bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "", req.Header.Get("X-BRI-Api-Version"))
+
+	bri.client.APIVersion = "2.0"
+	req, err = bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "2.0", req.Header.Get("X-BRI-Api-Version"))
+}
+
+func (bri *BriSanguTestSuite) TestNewRequestLanguage() {
+	req, err := bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "", req.Header.Get("Accept-Language"))
+
+	bri.client.Language = "en"
+	req, err = bri.client.NewRequest("GET", bri.client.BaseUrl, nil, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "en", req.Header.Get("Accept-Language"))
+}
+
+func (bri *BriSanguTestSuite) TestResponseRawResponse() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Write([]byte(`{"access_token":"token","expires_in":"179999"}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	resp, err := coreGateway.GetToken()
+
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "token", resp.AccessToken)
+	assert.Equal(bri.T(), http.StatusOK, resp.RawResponse().StatusCode)
+	assert.Equal(bri.T(), "99", resp.RawResponse().Header.Get("X-RateLimit-Remaining"))
+	assert.True(bri.T(), resp.RawResponse().Duration > 0)
+}
+
+func (bri *BriSanguTestSuite) TestVerifyResponseSignature() {
+	body := `{"access_token":"token","expires_in":"179999"}`
+	signature := generateResponseSignature("2020-01-01T00:00:00.000Z", body, bri.client.ClientSecret)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("BRI-Timestamp", "2020-01-01T00:00:00.000Z")
+		if r.URL.Query().Get("tampered") == "" {
+			w.Header().Set("BRI-Signature", signature)
+		} else {
+			w.Header().Set("BRI-Signature", "invalid")
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	bri.client.VerifyResponseSignature = true
+
+	var resp TokenResponse
+	err := bri.client.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "token", resp.AccessToken)
+
+	err = bri.client.Call("GET", server.URL+"?tampered=1", nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrInvalidResponseSignature))
+}
+
+func (bri *BriSanguTestSuite) TestExecuteRequestNotFound() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		if r.URL.Path == "/charges/inquiry" {
+			w.Write([]byte(`{"error":{"code":"0301","message":"Payment not found"},"status_code":404}`))
+		}
+	}))
+	defer server.Close()
+
+	var resp ChargeDetailResponse
+	err := bri.client.Call("POST", server.URL+"/charges/inquiry", nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrNotFound))
+	assert.Contains(bri.T(), err.Error(), `not found: {"error":{"code":"0301"`)
+	assert.Equal(bri.T(), "0301", resp.Error.Code)
+	assert.Equal(bri.T(), StatusCode("0301"), resp.Code())
+
+	err = bri.client.Call("POST", server.URL+"/unknown", nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrInvalidURL))
+	assert.Equal(bri.T(), "bri: POST "+server.URL+"/unknown: invalid url", err.Error())
+}
+
+func (bri *BriSanguTestSuite) TestExecuteRequestHTMLResponse() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/maintenance" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte("<html>\n<body>Under maintenance</body>\n</html>"))
+	}))
+	defer server.Close()
+
+	bri.client.DirectDebitBaseURL = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	err := coreGateway.CallDirectDebit("POST", "/maintenance", nil, nil, &ChargeDetailResponse{})
+	assert.True(bri.T(), errors.Is(err, ErrUnexpectedResponse))
+	assert.Contains(bri.T(), err.Error(), "<html> <body>Under maintenance</body> </html>")
+
+	err = coreGateway.CallDirectDebit("POST", "/charges", nil, nil, &PaymentChargeResponse{})
+	assert.True(bri.T(), errors.Is(err, ErrPendingTransaction))
+}
+
+func (bri *BriSanguTestSuite) TestExecuteRequestMaxResponseBytes() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"token","expires_in":"179999"}`))
+	}))
+	defer server.Close()
+
+	var resp TokenResponse
+	err := bri.client.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+
+	bri.client.MaxResponseBytes = 10
+	err = bri.client.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrResponseTooLarge))
+}
+
+func (bri *BriSanguTestSuite) TestExecuteRequestNilLogger() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	bri.client.Logger = nil
+	bri.client.LogLevel = 3
+
+	var resp TokenResponse
+	err := bri.client.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrInvalidURL))
+}
+
+func (bri *BriSanguTestSuite) TestReturnErrorsOnly() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	var buf bytes.Buffer
+	bri.client.Logger = log.New(&buf, "", 0)
+	bri.client.LogLevel = 1
+
+	err := bri.client.Call("GET", server.URL, nil, nil, nil, nil)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	assert.Contains(bri.T(), buf.String(), "Cannot send request")
+
+	buf.Reset()
+	bri.client.ReturnErrorsOnly = true
+	err = bri.client.Call("GET", server.URL, nil, nil, nil, nil)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	assert.Equal(bri.T(), "", buf.String())
+}
+
+func (bri *BriSanguTestSuite) TestClientWithoutLogger() {
+	client := Client{
+		BaseUrl:      bri.client.BaseUrl,
+		ClientSecret: "secret",
+		LogLevel:     3,
+	}
+
+	assert.NotPanics(bri.T(), func() {
+		client.signature("/v1/briva", "POST", "Bearer token", "2020-01-01T00:00:00.000Z", "")
+		_, err := client.NewRequest("GET", "://invalid", nil, nil)
+		assert.NotNil(bri.T(), err)
+	})
+}
+
+func (bri *BriSanguTestSuite) TestClientClose() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer server.Close()
+
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	assert.Equal(bri.T(), nil, coreGateway.Close())
+
+	var resp TokenResponse
+	err := coreGateway.Client.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), nil, coreGateway.Close())
+
+	// client is still usable after close
+	err = coreGateway.Client.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "token", resp.AccessToken)
+}
+
+func (bri *BriSanguTestSuite) TestClientHTTP2() {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"` + r.Proto + `"}`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	for _, http2 := range []bool{false, true} {
+		client := NewClient()
+		client.TLSConfig = &tls.Config{RootCAs: server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+		client.HTTP2 = http2
+
+		var resp TokenResponse
+		err := client.Call("GET", server.URL, nil, nil, &resp, nil)
+		assert.Equal(bri.T(), nil, err)
+		if http2 {
+			assert.Equal(bri.T(), "HTTP/2.0", resp.AccessToken)
+		} else {
+			assert.Equal(bri.T(), "HTTP/1.1", resp.AccessToken)
+		}
+	}
+}
+
+func (bri *BriSanguTestSuite) TestRequestID() {
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var callErr *Error
+	err := bri.client.Call("GET", server.URL, nil, nil, nil, nil)
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.Regexp(bri.T(), `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, callErr.RequestID)
+	assert.Equal(bri.T(), callErr.RequestID, requestIDs[0])
+
+	bri.client.Call("GET", server.URL, nil, nil, nil, nil)
+	assert.NotEqual(bri.T(), requestIDs[0], requestIDs[1])
+
+	// request id of the call takes precedence over RequestIDFunc
+	bri.client.RequestIDFunc = func() string { return "generated" }
+	err = bri.client.Call("GET", server.URL, nil, nil, nil, nil)
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.Equal(bri.T(), "generated", callErr.RequestID)
+
+	err = bri.client.Call("GET", server.URL, map[string]string{RequestIDHeader: "caller"}, nil, nil, nil)
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.Equal(bri.T(), "caller", callErr.RequestID)
+	assert.Equal(bri.T(), []string{"generated", "caller"}, requestIDs[2:])
+}
+
+func (bri *BriSanguTestSuite) TestNewClientFromEnv() {
+	for _, key := range []string{EnvClientID, EnvClientSecret, EnvAPIKey, EnvBaseURL, EnvDirectDebitBaseURL} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+
+	_, err := NewClientFromEnv()
+	assert.True(bri.T(), errors.Is(err, ErrMissingEnv))
+	assert.Contains(bri.T(), err.Error(), EnvClientID+", "+EnvClientSecret)
+
+	os.Setenv(EnvClientID, "id")
+	_, err = NewClientFromEnv()
+	assert.True(bri.T(), errors.Is(err, ErrMissingEnv))
+	assert.NotContains(bri.T(), err.Error(), EnvClientID)
+
+	os.Setenv(EnvClientSecret, "secret")
+	os.Setenv(EnvAPIKey, "key")
+	client, err := NewClientFromEnv()
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "id", client.ClientId)
+	assert.Equal(bri.T(), "secret", client.ClientSecret)
+	assert.Equal(bri.T(), "key", client.APIKey)
+}
+
+func (bri *BriSanguTestSuite) TestExecuteRequestDecodeByStatus() {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":false,"responseCode":"13","responseDescription":"Invalid Amount","errDesc":"amount is not valid"}`))
+	}))
+	defer server.Close()
+
+	var resp VaResponse
+	var respErr VaResponse
+	err := bri.client.Call("DELETE", server.URL, nil, nil, &resp, &respErr)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "", resp.ResponseCode)
+	assert.Equal(bri.T(), "13", respErr.ResponseCode)
+
+	status = http.StatusOK
+	resp, respErr = VaResponse{}, VaResponse{}
+	err = bri.client.Call("DELETE", server.URL, nil, nil, &resp, &respErr)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "13", resp.ResponseCode)
+	assert.Equal(bri.T(), "", respErr.ResponseCode)
+}
+
+func (bri *BriSanguTestSuite) TestClientNow() {
+	bri.client.DryRun = true
+	bri.client.Now = func() time.Time {
+		return time.Date(2020, 1, 1, 7, 0, 0, 123000000, time.FixedZone("WIB", 7*60*60))
+	}
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	req := CreateVaRequest{
+		InstitutionCode: "J104408",
+		BrivaNo:         "77777",
+		CustCode:        "123123",
+	}
+	_, err := coreGateway.CreateVA("token", req)
+
+	var dryRun *DryRunError
+	assert.True(bri.T(), errors.As(err, &dryRun))
+
+	timestamp := dryRun.Request.Header.Get("BRI-Timestamp")
+	assert.Equal(bri.T(), "2020-01-01T00:00:00.123Z", timestamp)
+
+	body, _ := json.Marshal(req)
+	signature := generateSignature(VA_PATH, "POST", "Bearer token", timestamp, string(body), bri.client.ClientSecret)
+	assert.Equal(bri.T(), signature, dryRun.Request.Header.Get("BRI-Signature"))
+}
+
+func (bri *BriSanguTestSuite) TestErrorRetrySafe() {
+	var callErr *Error
+
+	// connection refused, request never reaches BRI
+	err := bri.client.Call("POST", "http://127.0.0.1:1", nil, nil, nil, nil)
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.True(bri.T(), callErr.IsRetrySafe())
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+
+	// connection is closed after BRI receives the request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	err = bri.client.Call("POST", server.URL, nil, nil, nil, nil)
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.False(bri.T(), callErr.IsRetrySafe())
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+
+	err = bri.client.Call("POST", "://invalid", nil, nil, nil, nil)
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.True(bri.T(), callErr.IsRetrySafe())
+}
+
+func (bri *BriSanguTestSuite) TestCallEnvironmentMismatch() {
+	bri.client.IsProduction = false
+	err := bri.client.Call("POST", "https://partner.api.bri.co.id/v1/briva", nil, nil, nil, nil)
+	assert.True(bri.T(), errors.Is(err, ErrEnvironmentMismatch))
+
+	bri.client.IsProduction = true
+	err = bri.client.Call("POST", "https://sandbox.partner.api.bri.co.id/v1/briva", nil, nil, nil, nil)
+	assert.True(bri.T(), errors.Is(err, ErrEnvironmentMismatch))
+
+	var callErr *Error
+	assert.True(bri.T(), errors.As(err, &callErr))
+	assert.True(bri.T(), callErr.IsRetrySafe())
+}
+
+func (bri *BriSanguTestSuite) TestHeimdallOptions() {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	bri.client.HeimdallOptions = []httpclient.Option{httpclient.WithRetryCount(0)}
+	bri.client.Call("GET", server.URL, nil, nil, nil, nil)
+
+	assert.Equal(bri.T(), int32(1), atomic.LoadInt32(&calls))
+}
+
+func (bri *BriSanguTestSuite) TestRetryPredicate() {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(bri.T(), `{"amount":"10000"}`, string(body))
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Write([]byte(`{"responseCode":"5004700"}`))
+			return
+		}
+		w.Write([]byte(`{"responseCode":"2004700"}`))
+	}))
+	defer server.Close()
+
+	bri.client.RetryPredicate = func(req *http.Request, resp *http.Response, err error) bool {
+		if err != nil {
+			return true
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		return strings.Contains(string(body), "5004700")
+	}
+
+	var resp struct {
+		ResponseCode string `json:"responseCode"`
+	}
+	err := bri.client.Call("POST", server.URL, nil, strings.NewReader(`{"amount":"10000"}`), &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(bri.T(), "2004700", resp.ResponseCode)
+}
+
+func (bri *BriSanguTestSuite) TestCheckClockSkew() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Wed, 01 Jan 2020 00:00:00 GMT")
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	bri.client.Now = func() time.Time {
+		return time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC)
+	}
+
+	skew, err := bri.client.CheckClockSkew()
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), -30*time.Second, skew)
+
+	bri.client.MaxClockSkew = 10 * time.Second
+	skew, err = bri.client.CheckClockSkew()
+	assert.True(bri.T(), errors.Is(err, ErrClockSkew))
+	assert.Equal(bri.T(), -30*time.Second, skew)
+}
+
+func (bri *BriSanguTestSuite) TestDebugWriter() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		// request body is still sent after it is dumped
+		if strings.Contains(string(body), "client_id=") {
+			w.Write([]byte(`{"access_token":"secret-token","expires_in":"3599"}`))
+			return
+		}
+		if strings.Contains(string(body), "card_") {
+			w.Write([]byte(`{"body":{"status":"0000","card_token":"card-token-response","phone_number":"08123456789"}}`))
+			return
+		}
+		w.Write([]byte(`{"status":true,"responseCode":"00"}`))
+	}))
+	defer server.Close()
+
+	var dump bytes.Buffer
+	bri.client.BaseUrl = server.URL
+	bri.client.ClientSecret = "super-secret"
+	bri.client.DebugWriter = &dump
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	res, err := coreGateway.GetToken()
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "secret-token", res.AccessToken)
+
+	_, err = coreGateway.CreateVA("bearer-token", CreateVaRequest{InstitutionCode: "J104408", BrivaNo: "77777", CustCode: "1", Amount: "10000"})
+	assert.Equal(bri.T(), nil, err)
+
+	out := dump.String()
+	assert.Contains(bri.T(), out, "POST "+TOKEN_PATH)
+	assert.Contains(bri.T(), out, "client_secret=[REDACTED]")
+	assert.Contains(bri.T(), out, `"access_token":"[REDACTED]"`)
+	assert.Contains(bri.T(), out, "Authorization: [REDACTED]")
+	assert.Contains(bri.T(), out, `"brivaNo":"77777"`)
+	assert.Contains(bri.T(), out, "HTTP/1.1 200 OK")
+	assert.NotContains(bri.T(), out, "super-secret")
+	assert.NotContains(bri.T(), out, "secret-token")
+	assert.NotContains(bri.T(), out, "bearer-token")
+
+	// customer data of direct debit is redacted, card number is masked
+	dump.Reset()
+	coreGateway.Client.DirectDebitBaseURL = server.URL
+	coreGateway.Client.APIKey = "api_key"
+	_, err = coreGateway.CreateCardTokenOTP("bearer-token", NewCardTokenOTPRequest("5221843000000001", "08123456789", "user@example.com"))
+	assert.Equal(bri.T(), nil, err)
+	_, err = coreGateway.CreatePaymentChargeOTP("bearer-token", "key", NewPaymentChargeOTPRequest("card-token-request", NewMoney(1000000, CurrencyIDR), "payment"))
+	assert.Equal(bri.T(), nil, err)
+	_, err = coreGateway.CreatePaymentChargeOTPVerify("bearer-token", NewPaymentChargeOTPVerifyRequest("card-token-request", "charge", "999999"))
+	assert.Equal(bri.T(), nil, err)
+
+	out = dump.String()
+	assert.Contains(bri.T(), out, `"card_pan":"************0001"`)
+	assert.Contains(bri.T(), out, `"card_token":"[REDACTED]"`)
+	assert.NotContains(bri.T(), out, "5221843000000001")
+	assert.NotContains(bri.T(), out, "08123456789")
+	assert.NotContains(bri.T(), out, "card-token-request")
+	assert.NotContains(bri.T(), out, "card-token-response")
+	assert.NotContains(bri.T(), out, "999999")
+}
+
+func (bri *BriSanguTestSuite) TestRedactBody() {
+	body := `{"body":{"registration_token":"reg-1","charge_token":"charge-1","token":"token-1","token_type":"Bearer","status":"0000"}}`
+	assert.Equal(bri.T(), `{"body":{"registration_token":"[REDACTED]","charge_token":"[REDACTED]","token":"[REDACTED]","token_type":"Bearer","status":"0000"}}`,
+		string(redactBody([]byte(body))))
+
+	assert.Equal(bri.T(), "token=[REDACTED]&charge_token=[REDACTED]&amount=1000", string(redactBody([]byte("token=token-1&charge_token=charge-1&amount=1000"))))
+}
+
+func (bri *BriSanguTestSuite) TestAuthErrorStatus() {
+	var status int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"responseCode":"01","responseDescription":"Access Token Invalid"}`))
+	}))
+	defer server.Close()
+
+	var resp struct {
+		ResponseCode string `json:"responseCode"`
+	}
+
+	status = http.StatusUnauthorized
+	err := bri.client.Call("POST", server.URL, nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrUnauthorized))
+	// body is still decoded
+	assert.Equal(bri.T(), "01", resp.ResponseCode)
+
+	status = http.StatusForbidden
+	err = bri.client.Call("POST", server.URL, nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrForbidden))
+	assert.False(bri.T(), errors.Is(err, ErrUnauthorized))
+}
+
+func (bri *BriSanguTestSuite) TestResponseHeaderTimeout() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hung" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// body of a large report is slow, but header is sent right away
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ResponseHeaderTimeout = 100 * time.Millisecond
+
+	var resp TokenResponse
+	err := client.Call("GET", server.URL+"/report", nil, nil, &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "token", resp.AccessToken)
+
+	err = client.Call("GET", server.URL+"/hung", nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+
+	transport := client.newTransport()
+	assert.Equal(bri.T(), defHTTPTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	client.TLSHandshakeTimeout = time.Second
+	assert.Equal(bri.T(), time.Second, client.newTransport().TLSHandshakeTimeout)
+}
+
+func (bri *BriSanguTestSuite) TestHTTPClientPerSetting() {
+	base := NewClient()
+	same := base
+	assert.True(bri.T(), base.getHTTPClient() == same.getHTTPClient())
+
+	// copies with different http setting don't use the client of the first copy
+	fast := base
+	fast.Timeout = time.Second
+	assert.True(bri.T(), base.getHTTPClient() != fast.getHTTPClient())
+
+	http2 := base
+	http2.HTTP2 = true
+	assert.True(bri.T(), base.getHTTPClient() != http2.getHTTPClient())
+
+	predicate := base
+	predicate.RetryPredicate = DefaultRetryPredicate
+	assert.True(bri.T(), base.getHTTPClient() != predicate.getHTTPClient())
+
+	// func setting can't be compared, closures of the same code are not shared
+	retryOnce := base
+	retryOnce.HeimdallOptions = []httpclient.Option{httpclient.WithRetryCount(1)}
+	retryMore := base
+	retryMore.HeimdallOptions = []httpclient.Option{httpclient.WithRetryCount(5)}
+	assert.True(bri.T(), retryOnce.getHTTPClient() != retryMore.getHTTPClient())
+	assert.True(bri.T(), retryOnce.getHTTPClient() != retryOnce.getHTTPClient())
+
+	// setting is honoured by the copy
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var resp TokenResponse
+	assert.Equal(bri.T(), nil, base.Call("GET", server.URL, nil, nil, &resp, nil))
+
+	fast.Timeout = 20 * time.Millisecond
+	err := fast.Call("GET", server.URL, nil, nil, &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	assert.Equal(bri.T(), nil, base.Close())
+}
+
+func (bri *BriSanguTestSuite) TestHTTPClientNotSharedClosesConnection() {
+	var closed []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		closed = append(closed, r.Close)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var resp TokenResponse
+	shared := NewClient()
+	assert.Equal(bri.T(), nil, shared.Call("GET", server.URL, nil, nil, &resp, nil))
+
+	// client without shared transport doesn't leave idle connection behind
+	handBuilt := Client{Timeout: time.Second}
+	assert.Equal(bri.T(), nil, handBuilt.Call("GET", server.URL, nil, nil, &resp, nil))
+
+	predicate := NewClient()
+	predicate.RetryPredicate = DefaultRetryPredicate
+	assert.Equal(bri.T(), nil, predicate.Call("GET", server.URL, nil, nil, &resp, nil))
+
+	assert.Equal(bri.T(), []bool{false, true, true}, closed)
+	assert.Equal(bri.T(), nil, shared.Close())
+}
@@ -2,10 +2,18 @@ package bri
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
 	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,18 +23,127 @@ func getTimestamp(format string) (timestamp string) {
 	return
 }
 
-func generateSignature(path string, method string, token string, timestamp string, body string, secret string) (sig string) {
-	payload := "path=" + path +
+// StringToSign returns the canonical string which is signed by BRI-Signature.
+// Use it to compare the signed string with BRI expectation when debugging signature rejection.
+func StringToSign(path string, method string, token string, timestamp string, body string) string {
+	return "path=" + path +
 		"&verb=" + method +
 		"&token=" + token +
 		"&timestamp=" + timestamp +
 		"&body=" + body
+}
 
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(payload))
+// signaturePath returns path with its query string as signed by BRI for GET requests.
+// Query parameters are sorted by key and url encoded, the same form which is sent in the request url.
+// GET requests are signed with empty body.
+func signaturePath(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
 
-	sig = base64.StdEncoding.EncodeToString(h.Sum(nil))
-	return
+	return path + "?" + query.Encode()
+}
+
+// coreHeaders returns headers of signed core api request. Content-Type is not set if contentType is empty, e.g. for GET request.
+func coreHeaders(token, timestamp, signature, contentType string) map[string]string {
+	headers := map[string]string{
+		"Authorization": token,
+		"BRI-Timestamp": timestamp,
+		"BRI-Signature": signature,
+	}
+
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	return headers
+}
+
+// formBody encodes url.Values, map[string]string or struct into application/x-www-form-urlencoded body.
+// Struct fields are encoded using their `form` tag, fields without the tag are skipped.
+// Values are url escaped, so secret containing special character, e.g. "+" or "&", is sent as is.
+func formBody(v interface{}) (io.Reader, error) {
+	values := url.Values{}
+
+	switch data := v.(type) {
+	case url.Values:
+		values = data
+	case map[string]string:
+		for key, value := range data {
+			values.Set(key, value)
+		}
+	default:
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		if rv.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("form body: unsupported type %T", v)
+		}
+
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			key := field.Tag.Get("form")
+			// unexported field can't be read
+			if key == "" || key == "-" || field.PkgPath != "" {
+				continue
+			}
+			values.Set(key, fmt.Sprint(rv.Field(i).Interface()))
+		}
+	}
+
+	return strings.NewReader(values.Encode()), nil
+}
+
+// SignatureAlgorithm is hash algorithm of HMAC request signature
+type SignatureAlgorithm int
+
+const (
+	// HMACSHA256 is used by legacy BRI API, e.g. BRIVA and direct debit
+	HMACSHA256 SignatureAlgorithm = iota
+	// HMACSHA512 is used by SNAP BI transactional request
+	HMACSHA512
+)
+
+// String returns name of the algorithm
+func (a SignatureAlgorithm) String() string {
+	switch a {
+	case HMACSHA512:
+		return "HMAC-SHA512"
+	default:
+		return "HMAC-SHA256"
+	}
+}
+
+// hash returns hash constructor of the algorithm, HMACSHA256 is used for unknown value
+func (a SignatureAlgorithm) hash() func() hash.Hash {
+	switch a {
+	case HMACSHA512:
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+// GenerateHMACSignature signs stringToSign using HMAC of the given algorithm and returns it base64 encoded.
+// Every BRI HMAC signature, legacy or SNAP BI, is produced by this function.
+func GenerateHMACSignature(algorithm SignatureAlgorithm, secret, stringToSign string) string {
+	h := hmac.New(algorithm.hash(), []byte(secret))
+	h.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func generateSignature(path string, method string, token string, timestamp string, body string, secret string) (sig string) {
+	payload := StringToSign(path, method, token, timestamp, body)
+
+	return GenerateHMACSignature(HMACSHA256, secret, payload)
+}
+
+// generateResponseSignature generates expected signature of BRI response from its timestamp header and body
+func generateResponseSignature(timestamp string, body string, secret string) (sig string) {
+	payload := "timestamp=" + timestamp +
+		"&body=" + body
+
+	return GenerateHMACSignature(HMACSHA256, secret, payload)
 }
 
 // generateSha1Timestamp will generate sha1 hash from UnixNano timestamp
@@ -37,3 +154,45 @@ func generateSha1Timestamp(salt string) string {
 	h.Write([]byte(key))
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
+
+// newRequestID returns random (version 4) UUID used as request id
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// MaxReferenceLength is maximum length of merchant transaction reference accepted by BRI, e.g. NoReferral
+const MaxReferenceLength = 20
+
+// referenceChars are characters of the random part of reference
+const referenceChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// GenerateReference generates unique transaction reference, e.g. for NoReferral of transfer or PartnerReferenceNo.
+// It is prefix followed by base36 time and random part, alphanumeric and at most MaxReferenceLength characters.
+// Non alphanumeric characters of prefix are removed and prefix is truncated to fit the length.
+func GenerateReference(prefix string) string {
+	random := make([]byte, 6)
+	rand.Read(random)
+	for i, b := range random {
+		random[i] = referenceChars[int(b)%len(referenceChars)]
+	}
+	suffix := strings.ToUpper(strconv.FormatInt(time.Now().Unix(), 36)) + string(random)
+
+	var sanitized strings.Builder
+	for _, c := range prefix {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			sanitized.WriteRune(c)
+		}
+	}
+
+	prefix = sanitized.String()
+	if maxPrefix := MaxReferenceLength - len(suffix); len(prefix) > maxPrefix {
+		prefix = prefix[:maxPrefix]
+	}
+
+	return prefix + suffix
+}
@@ -0,0 +1,89 @@
+package bri
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestStringToSign() {
+	stringToSign := StringToSign("/v1/briva", "POST", "Bearer token", "2020-01-01T00:00:00.000Z", `{"brivaNo":"77777"}`)
+
+	assert.Equal(bri.T(), `path=/v1/briva&verb=POST&token=Bearer token&timestamp=2020-01-01T00:00:00.000Z&body={"brivaNo":"77777"}`, stringToSign)
+}
+
+func (bri *BriSanguTestSuite) TestSignatureGetWithQuery() {
+	query := url.Values{}
+	query.Set("beneficiaryaccount", "888801000157508")
+	query.Set("bankcode", "002")
+	path := signaturePath("/v2/transfer/accounts", query)
+
+	assert.Equal(bri.T(), "/v2/transfer/accounts?bankcode=002&beneficiaryaccount=888801000157508", path)
+	assert.Equal(bri.T(), "/v2/transfer/accounts", signaturePath("/v2/transfer/accounts", nil))
+
+	signature := generateSignature(path, "GET", "Bearer token", "2020-01-01T00:00:00.000Z", "", "secret")
+	assert.Equal(bri.T(), "NqCUIuKojCxCH4NyPX7vbsX4Oi+svUcXq8bWfjY2x2Y=", signature)
+}
+
+func (bri *BriSanguTestSuite) TestFormBody() {
+	body, err := formBody(map[string]string{
+		"client_id":     "id",
+		"client_secret": "a+b&c=d e",
+	})
+	assert.Equal(bri.T(), nil, err)
+
+	encoded, _ := ioutil.ReadAll(body)
+	assert.Equal(bri.T(), "client_id=id&client_secret=a%2Bb%26c%3Dd+e", string(encoded))
+
+	values, err := url.ParseQuery(string(encoded))
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "a+b&c=d e", values.Get("client_secret"))
+
+	body, err = formBody(struct {
+		GrantType string `form:"grant_type"`
+		Limit     int    `form:"limit"`
+		Ignored   string
+	}{"client_credentials", 10, "x"})
+	assert.Equal(bri.T(), nil, err)
+
+	encoded, _ = ioutil.ReadAll(body)
+	assert.Equal(bri.T(), "grant_type=client_credentials&limit=10", string(encoded))
+
+	_, err = formBody("string")
+	assert.NotNil(bri.T(), err)
+}
+
+func (bri *BriSanguTestSuite) TestGenerateReference() {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		ref := GenerateReference("INV")
+		assert.Regexp(bri.T(), `^INV[0-9A-Z]{12}$`, ref)
+		assert.False(bri.T(), seen[ref])
+		seen[ref] = true
+	}
+
+	ref := GenerateReference("order-2021/11#payroll")
+	assert.Regexp(bri.T(), `^order202[0-9A-Z]{12}$`, ref)
+	assert.Equal(bri.T(), MaxReferenceLength, len(ref))
+}
+
+func (bri *BriSanguTestSuite) TestGenerateHMACSignature() {
+	stringToSign := "path=/v1/briva&verb=POST"
+
+	h := hmac.New(sha256.New, []byte("secret"))
+	h.Write([]byte(stringToSign))
+	assert.Equal(bri.T(), base64.StdEncoding.EncodeToString(h.Sum(nil)), GenerateHMACSignature(HMACSHA256, "secret", stringToSign))
+
+	h = hmac.New(sha512.New, []byte("secret"))
+	h.Write([]byte(stringToSign))
+	assert.Equal(bri.T(), base64.StdEncoding.EncodeToString(h.Sum(nil)), GenerateHMACSignature(HMACSHA512, "secret", stringToSign))
+
+	// legacy and SNAP BI signatures are produced by the same function
+	assert.Equal(bri.T(), GenerateHMACSignature(HMACSHA512, "secret", stringToSign), GenerateSignatureSymmetric("secret", stringToSign))
+	assert.Equal(bri.T(), "HMAC-SHA512", HMACSHA512.String())
+}
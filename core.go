@@ -1,57 +1,166 @@
 package bri
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 const (
-	TOKEN_PATH      = "/oauth/client_credential/accesstoken?grant_type=client_credentials"
-	VA_PATH         = "/v1/briva"
-	VA_REPORT_PATH  = "/v1/briva/report"
-	MUTATION_PATH   = "/v2.0/statement"
-	BRI_TIME_FORMAT = "2006-01-02T15:04:05.999Z"
+	TOKEN_PATH               = "/oauth/client_credential/accesstoken?grant_type=client_credentials"
+	VA_PATH                  = "/v1/briva"
+	VA_REPORT_PATH           = "/v1/briva/report"
+	VA_EXPIRY_PATH           = "/v1/briva/expired"
+	VA_SIMULATE_PAYMENT_PATH = "/sandbox/v1/briva/payment"
+	MUTATION_PATH            = "/v2.0/statement"
+	BALANCE_PATH             = "/v2/inquiry"
+	BRI_TIME_FORMAT          = "2006-01-02T15:04:05.999Z"
+)
+
+const (
+	VA_REPORT_PAGE_LIMIT = 100
+
+	VA_RESP_CODE_SUCCESS               = "00"
+	VA_REPORT_RESP_CODE_NO_TRANSACTION = "41"
 )
 
 // CoreGateway struct
 type CoreGateway struct {
 	Client Client
+
+	tokenCache *tokenCache
 }
 
-// Call : base method to call Core API
+// Call : base method to call Core API. path is relative to Client.BaseUrl.
 func (gateway *CoreGateway) Call(method, path string, header map[string]string, body io.Reader, v interface{}, vErr interface{}) error {
+	return gateway.call(context.Background(), method, path, header, body, v, vErr)
+}
+
+func (gateway *CoreGateway) call(ctx context.Context, method, path string, header map[string]string, body io.Reader, v interface{}, vErr interface{}) error {
 	if !strings.HasPrefix(path, "/") {
 		path = "/" + path
 	}
 
-	path = gateway.Client.BaseUrl + path
+	if gateway.Client.BaseUrl == "" {
+		return ErrMissingBaseURL
+	}
+
+	if gateway.Client.ClientSecret == "" {
+		return ErrMissingClientSecret
+	}
+
+	ctx = gateway.Client.withSignPath(ctx, path)
+
+	if len(gateway.Client.FailoverBaseURLs) == 0 {
+		path = strings.TrimSuffix(gateway.Client.BaseUrl, "/") + path
+		return gateway.Client.call(ctx, method, path, header, body, v, vErr)
+	}
+
+	// body is buffered so it can be sent again to the failover hosts
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = ioutil.ReadAll(body); err != nil {
+			return err
+		}
+	}
 
-	return gateway.Client.Call(method, path, header, body, v, vErr)
+	baseURLs := append([]string{gateway.Client.BaseUrl}, gateway.Client.FailoverBaseURLs...)
+
+	var err error
+	for _, baseURL := range baseURLs {
+		err = gateway.Client.call(ctx, method, strings.TrimSuffix(baseURL, "/")+path, header, bytes.NewReader(payload), v, vErr)
+		// only fail over if the request never reached BRI, e.g. timeout after the request is written may have been processed
+		var callErr *Error
+		if !errors.Is(err, ErrConnection) || !errors.As(err, &callErr) || !callErr.IsRetrySafe() {
+			return err
+		}
+	}
+
+	return err
 }
 
-// CallDirectDebit will call direct debit api
+// CallDirectDebit will call direct debit api. path is relative to Client.DirectDebitBaseURL.
+// If BRI responds with error, it is decoded into ErrorResponse of v and *BRIError is returned.
 func (gateway *CoreGateway) CallDirectDebit(method, path string, header map[string]string, body io.Reader, v interface{}) error {
+	return gateway.callDirectDebit(context.Background(), method, path, header, body, v)
+}
+
+// callDirectDebit is CallDirectDebit bound to ctx
+func (gateway *CoreGateway) callDirectDebit(ctx context.Context, method, path string, header map[string]string, body io.Reader, v interface{}) error {
 	if !strings.HasPrefix(path, "/") {
 		path = "/" + path
 	}
 
-	path = gateway.Client.DirectDebitBaseURL + path
-	return gateway.Client.Call(method, path, header, body, v, nil)
+	if gateway.Client.DirectDebitBaseURL == "" {
+		return ErrMissingDirectDebitBaseURL
+	}
+
+	if gateway.Client.ClientSecret == "" {
+		return ErrMissingClientSecret
+	}
+
+	if !gateway.Client.IsProduction && gateway.Client.APIKey == "" {
+		return ErrMissingAPIKey
+	}
+
+	ctx = gateway.Client.withSignPath(ctx, path)
+	path = strings.TrimSuffix(gateway.Client.DirectDebitBaseURL, "/") + path
+	err := gateway.Client.call(ctx, method, path, header, body, v, nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	// direct debit error is decoded into ErrorResponse embedded in v, it is more specific than ErrNotFound
+	if r, ok := v.(errorResponder); ok {
+		if briErr := r.errorResponse().briError(); briErr != nil {
+			return briErr
+		}
+	}
+
+	return err
+}
+
+// Close releases resources held by the gateway client, see Client.Close
+func (gateway *CoreGateway) Close() error {
+	return gateway.Client.Close()
 }
 
+// GetToken requests access token, bounded by Client.TokenTimeout if it is set
 func (gateway *CoreGateway) GetToken() (res TokenResponse, err error) {
-	data := url.Values{}
-	data.Set("client_id", gateway.Client.ClientId)
-	data.Set("client_secret", gateway.Client.ClientSecret)
+	return gateway.getToken(context.Background())
+}
+
+// getToken is GetToken bound to ctx
+func (gateway *CoreGateway) getToken(ctx context.Context) (res TokenResponse, err error) {
+	if gateway.Client.ClientId == "" {
+		err = ErrMissingClientID
+		return
+	}
+
+	body, err := formBody(map[string]string{
+		"client_id":     gateway.Client.ClientId,
+		"client_secret": gateway.Client.ClientSecret,
+	})
+	if err != nil {
+		return
+	}
 
 	headers := map[string]string{
-		"Content-Type": "application/x-www-form-urlencoded",
+		"Content-Type": ContentTypeForm,
 	}
 
-	err = gateway.Call("POST", TOKEN_PATH, headers, strings.NewReader(data.Encode()), &res, nil)
+	ctx, cancel := gateway.Client.tokenContext(ctx)
+	defer cancel()
+
+	err = gateway.call(ctx, "POST", TOKEN_PATH, headers, body, &res, nil)
 	if err != nil {
 		return
 	}
@@ -63,15 +172,10 @@ func (gateway *CoreGateway) CreateVA(token string, req CreateVaRequest) (res VaR
 	token = "Bearer " + token
 	method := "POST"
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(VA_PATH, method, token, timestamp, string(body), gateway.Client.ClientSecret)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(VA_PATH, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization": token,
-		"BRI-Timestamp": timestamp,
-		"BRI-Signature": signature,
-		"Content-Type":  "application/json",
-	}
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
 
 	err = gateway.Call(method, VA_PATH, headers, strings.NewReader(string(body)), &res, nil)
 
@@ -82,19 +186,21 @@ func (gateway *CoreGateway) CreateVA(token string, req CreateVaRequest) (res VaR
 	return
 }
 
+// CreateStaticBRIVA creates static VA which accepts multiple payments to the same VA number, e.g. for recurring billing.
+// Every payment is a separate record in GetReportVA, use VaReportResponse.PaymentsByVA to group them.
+func (gateway *CoreGateway) CreateStaticBRIVA(token string, req CreateVaRequest) (res VaResponse, err error) {
+	req.AccountType = BRIVAAccountTypeStatic
+	return gateway.CreateVA(token, req)
+}
+
 func (gateway *CoreGateway) UpdateVA(token string, req CreateVaRequest) (res VaResponse, err error) {
 	token = "Bearer " + token
 	method := "PUT"
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(VA_PATH, method, token, timestamp, string(body), gateway.Client.ClientSecret)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(VA_PATH, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization": token,
-		"BRI-Timestamp": timestamp,
-		"BRI-Signature": signature,
-		"Content-Type":  "application/json",
-	}
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
 
 	err = gateway.Call(method, VA_PATH, headers, strings.NewReader(string(body)), &res, nil)
 
@@ -105,21 +211,57 @@ func (gateway *CoreGateway) UpdateVA(token string, req CreateVaRequest) (res VaR
 	return
 }
 
+// UpdateBRIVAExpiry extends expiry of an existing VA without changing its VA number.
+// Data.ExpiredDate of the response is the new expiry.
+func (gateway *CoreGateway) UpdateBRIVAExpiry(token string, req UpdateBRIVAExpiryRequest) (res UpdateBRIVAExpiryResponse, err error) {
+	token = "Bearer " + token
+	method := "PUT"
+	body, err := json.Marshal(req)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(VA_EXPIRY_PATH, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = gateway.Call(method, VA_EXPIRY_PATH, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// SimulatePaymentBRIVA simulates customer payment of a VA in BRI sandbox, e.g. to test the paid flow end-to-end.
+// ErrSandboxOnly is returned without calling BRI if Client.IsProduction is true.
+func (gateway *CoreGateway) SimulatePaymentBRIVA(token string, req SimulatePaymentRequest) (res SimulatePaymentResponse, err error) {
+	if gateway.Client.IsProduction {
+		err = ErrSandboxOnly
+		return
+	}
+
+	token = "Bearer " + token
+	method := "POST"
+	body, err := json.Marshal(req)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(VA_SIMULATE_PAYMENT_PATH, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = gateway.Call(method, VA_SIMULATE_PAYMENT_PATH, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
 func (gateway *CoreGateway) GetReportVA(token string, req GetReportVaRequest) (res VaReportResponse, err error) {
+	return gateway.getReportVA(context.Background(), token, req)
+}
+
+// getReportVA is GetReportVA bound to ctx
+func (gateway *CoreGateway) getReportVA(ctx context.Context, token string, req GetReportVaRequest) (res VaReportResponse, err error) {
 	token = "Bearer " + token
 	method := "GET"
 	body := ""
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	path := VA_REPORT_PATH + "/" + req.InstitutionCode + "/" + req.BrivaNo + "/" + req.StartDate + "/" + req.EndDate
-	signature := generateSignature(path, method, token, timestamp, string(body), gateway.Client.ClientSecret)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	path := vaReportPath(req)
+	signature := gateway.Client.signature(path, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization": token,
-		"BRI-Timestamp": timestamp,
-		"BRI-Signature": signature,
-	}
+	headers := coreHeaders(token, timestamp, signature, "")
 
-	err = gateway.Call(method, path, headers, strings.NewReader(string(body)), &res, nil)
+	err = gateway.call(ctx, method, path, headers, strings.NewReader(string(body)), &res, nil)
 
 	if err != nil {
 		return
@@ -128,19 +270,77 @@ func (gateway *CoreGateway) GetReportVA(token string, req GetReportVaRequest) (r
 	return
 }
 
+// vaReportPath returns signed path of VA report request
+func vaReportPath(req GetReportVaRequest) string {
+	path := VA_REPORT_PATH + "/" + req.InstitutionCode + "/" + req.BrivaNo + "/" + req.StartDate + "/" + req.EndDate
+	query := url.Values{}
+	if req.Page > 0 || req.Limit > 0 {
+		query.Set("page", strconv.Itoa(req.Page))
+		query.Set("limit", strconv.Itoa(req.Limit))
+	}
+
+	return signaturePath(path, query)
+}
+
+// GetReportVAAll iterates all report pages starting from req.Page (default 1) and returns the concatenated report data.
+// Iteration stops at the first empty or partial page. If a page fails, data collected so far is returned along with the error.
+// A page in flight is aborted when ctx is done.
+func (gateway *CoreGateway) GetReportVAAll(ctx context.Context, token string, req GetReportVaRequest) (res VaReportResponse, err error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = VA_REPORT_PAGE_LIMIT
+	}
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		var page VaReportResponse
+		page, err = gateway.getReportVA(ctx, token, req)
+		if err != nil {
+			return
+		}
+
+		res.Status = page.Status
+		res.ResponseCode = page.ResponseCode
+		res.Description = page.Description
+		res.ErrDesc = page.ErrDesc
+		res.ResponseMeta = page.ResponseMeta
+
+		// no transaction on this page, previous page was the last one
+		if page.ResponseCode == VA_REPORT_RESP_CODE_NO_TRANSACTION {
+			if len(res.Data) > 0 {
+				res.Status = true
+				res.ResponseCode = VA_RESP_CODE_SUCCESS
+			}
+			return
+		}
+
+		if !page.Status {
+			err = fmt.Errorf("get report va page %d: %s %s", req.Page, page.ResponseCode, page.Description)
+			return
+		}
+
+		res.Data = append(res.Data, page.Data...)
+		if len(page.Data) < req.Limit {
+			return
+		}
+
+		req.Page++
+	}
+}
+
 func (gateway *CoreGateway) DeleteVA(token string, institutionCode string, brivaNo string, custCode string) (res VaResponse, respErr ErrorResponse, err error) {
 	token = "Bearer " + token
 	method := "DELETE"
 	body := fmt.Sprintf("institutionCode=%s&brivaNo=%s&custCode=%s", institutionCode, brivaNo, custCode)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(VA_PATH, method, token, timestamp, body, gateway.Client.ClientSecret)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(VA_PATH, method, token, timestamp, body)
 
-	headers := map[string]string{
-		"Authorization": token,
-		"BRI-Timestamp": timestamp,
-		"BRI-Signature": signature,
-		"Content-Type":  "text/plain",
-	}
+	headers := coreHeaders(token, timestamp, signature, ContentTypeText)
 
 	err = gateway.Call(method, VA_PATH, headers, strings.NewReader(string(body)), &res, &respErr)
 
@@ -155,17 +355,12 @@ func (gateway *CoreGateway) GetMutation(token string, req GetMutationRequest) (r
 	token = "Bearer " + token
 	method := "POST"
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(MUTATION_PATH, method, token, timestamp, string(body), gateway.Client.ClientSecret)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(MUTATION_PATH, method, token, timestamp, string(body))
 	externalId := generateSha1Timestamp("mutation")
 
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"BRI-Signature":   signature,
-		"BRI-External-Id": externalId,
-		"Content-Type":    "application/json",
-	}
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["BRI-External-Id"] = externalId
 
 	err = gateway.Call(method, MUTATION_PATH, headers, strings.NewReader(string(body)), &res, nil)
 
@@ -175,3 +370,80 @@ func (gateway *CoreGateway) GetMutation(token string, req GetMutationRequest) (r
 
 	return
 }
+
+// AccountStatement returns account mutation entries between req.StartDate and req.EndDate with amounts parsed as Money.
+// If req.Limit is set, pages are requested starting from req.Page (default 1) until an empty or partial page is returned.
+// If a page fails, entries collected so far are returned along with the error. A page in flight is aborted when ctx is done.
+func (gateway *CoreGateway) AccountStatement(ctx context.Context, token string, req AccountStatementRequest) (res AccountStatementResponse, err error) {
+	if req.Limit > 0 && req.Page < 1 {
+		req.Page = 1
+	}
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		var page AccountStatementResponse
+		page, err = gateway.accountStatementPage(ctx, token, req)
+		if err != nil {
+			return
+		}
+
+		res.ResponseCode = page.ResponseCode
+		res.ResponseDescription = page.ResponseDescription
+		res.ErrDesc = page.ErrDesc
+		res.ResponseMeta = page.ResponseMeta
+
+		if page.ResponseCode != MutationRespCodeSuccess {
+			err = fmt.Errorf("account statement: %s %s", page.ResponseCode, page.ResponseDescription)
+			return
+		}
+
+		res.Data = append(res.Data, page.Data...)
+		if req.Limit < 1 || len(page.Data) < req.Limit {
+			return
+		}
+
+		req.Page++
+	}
+}
+
+func (gateway *CoreGateway) accountStatementPage(ctx context.Context, token string, req AccountStatementRequest) (res AccountStatementResponse, err error) {
+	token = "Bearer " + token
+	method := "POST"
+	body, err := json.Marshal(req)
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	signature := gateway.Client.signature(MUTATION_PATH, method, token, timestamp, string(body))
+	externalId := generateSha1Timestamp("statement")
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["BRI-External-Id"] = externalId
+
+	err = gateway.call(ctx, method, MUTATION_PATH, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+func (gateway *CoreGateway) InquiryBalance(token string, req BalanceInquiryRequest) (res BalanceInquiryResponse, err error) {
+	token = "Bearer " + token
+	method := "GET"
+	body := ""
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	path := BALANCE_PATH + "/" + req.AccountNumber
+	signature := gateway.Client.signature(path, method, token, timestamp, body)
+
+	headers := coreHeaders(token, timestamp, signature, "")
+
+	err = gateway.Call(method, path, headers, strings.NewReader(body), &res, nil)
+
+	if err != nil {
+		return
+	}
+
+	if res.ResponseCode == BalanceRespCodeInvalidAccount {
+		err = ErrInvalidAccountNumber
+		return
+	}
+
+	return
+}
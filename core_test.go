@@ -1,11 +1,17 @@
 package bri
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,7 +32,7 @@ type BriSanguTestSuite struct {
 	cardToken             string
 	chargeToken           string
 	paymentID             string
-	amount                string
+	amount                Money
 
 	// property for mutation
 	accNumber string
@@ -392,3 +398,385 @@ func (bri *BriSanguTestSuite) TestGetMutationSuccess() {
 	assert.Equal(bri.T(), "0000", resp.ResponseCode)
 	assert.Equal(bri.T(), nil, err)
 }
+
+func (bri *BriSanguTestSuite) TestInquiryBalanceSuccess() {
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	tokenResp, err := coreGateway.GetToken()
+	bri.Require().NoError(err)
+
+	req := BalanceInquiryRequest{
+		AccountNumber: bri.accNumber,
+	}
+
+	token := tokenResp.AccessToken
+	resp, err := coreGateway.InquiryBalance(token, req)
+
+	assert.Equal(bri.T(), "0000", resp.ResponseCode)
+	assert.Equal(bri.T(), nil, err)
+}
+
+func (bri *BriSanguTestSuite) TestInquiryBalanceFailedInvalidAccount() {
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	tokenResp, err := coreGateway.GetToken()
+	bri.Require().NoError(err)
+
+	req := BalanceInquiryRequest{
+		AccountNumber: "000000000000000",
+	}
+
+	token := tokenResp.AccessToken
+	resp, err := coreGateway.InquiryBalance(token, req)
+
+	assert.Equal(bri.T(), BalanceRespCodeInvalidAccount, resp.ResponseCode)
+	assert.Equal(bri.T(), ErrInvalidAccountNumber, err)
+}
+
+func (bri *BriSanguTestSuite) TestCallMissingBaseURL() {
+	bri.client.BaseUrl = ""
+	bri.client.DirectDebitBaseURL = ""
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	_, err := coreGateway.GetToken()
+	assert.Equal(bri.T(), ErrMissingBaseURL, err)
+
+	_, err = coreGateway.GetChargeDetail("token", ChargeDetailRequest{})
+	assert.Equal(bri.T(), ErrMissingDirectDebitBaseURL, err)
+}
+
+func (bri *BriSanguTestSuite) TestGetTokenTimeout() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"access_token":"token","expires_in":"3599"}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	bri.client.TokenTimeout = 50 * time.Millisecond
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	start := time.Now()
+	_, err := coreGateway.GetToken()
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	assert.True(bri.T(), time.Since(start) < 150*time.Millisecond)
+
+	// other calls are not bounded by TokenTimeout
+	var resp TokenResponse
+	err = coreGateway.Call("GET", "/slow", nil, nil, &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+}
+
+func (bri *BriSanguTestSuite) TestCallMissingCredential() {
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	coreGateway.Client.APIKey = ""
+	_, err := coreGateway.GetChargeDetail("token", ChargeDetailRequest{})
+	assert.Equal(bri.T(), ErrMissingAPIKey, err)
+
+	coreGateway.Client.ClientSecret = ""
+	_, err = coreGateway.GetToken()
+	assert.Equal(bri.T(), ErrMissingClientSecret, err)
+
+	_, err = coreGateway.GetChargeDetail("token", ChargeDetailRequest{})
+	assert.Equal(bri.T(), ErrMissingClientSecret, err)
+
+	coreGateway.Client.ClientId = ""
+	_, err = coreGateway.GetToken()
+	assert.Equal(bri.T(), ErrMissingClientID, err)
+}
+
+func (bri *BriSanguTestSuite) TestAccountStatementPaging() {
+	var pages []int
+	var cancel context.CancelFunc
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AccountStatementRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		pages = append(pages, req.Page)
+
+		if cancel != nil && req.Page == 2 {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+
+		if req.Page == 1 {
+			w.Write([]byte(`{"responseCode":"0000","data":[
+				{"transactionTime":"2020-01-01 10:00:00","debitAmount":"0.00","creditAmount":"10000.50","typeAmount":"C","startBalance":"0.00","endBalance":"10000.50"},
+				{"transactionTime":"2020-01-01 11:00:00","debitAmount":"500.25","creditAmount":"0.00","typeAmount":"D","startBalance":"10000.50","endBalance":"9500.25"}]}`))
+			return
+		}
+		w.Write([]byte(`{"responseCode":"0000","data":[
+			{"transactionTime":"2020-01-02 10:00:00","debitAmount":"0.00","creditAmount":"0.75","typeAmount":"C","startBalance":"9500.25","endBalance":"9501.00"}]}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	req := AccountStatementRequest{
+		AccountNumber: bri.accNumber,
+		StartDate:     "2020-01-01",
+		EndDate:       "2020-01-02",
+		Limit:         2,
+	}
+	resp, err := coreGateway.AccountStatement(context.Background(), "token", req)
+
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), []int{1, 2}, pages)
+	assert.Equal(bri.T(), 3, len(resp.Data))
+	assert.Equal(bri.T(), int64(1000050), resp.Data[0].CreditAmount.Value)
+	assert.Equal(bri.T(), int64(50025), resp.Data[1].DebitAmount.Value)
+	assert.Equal(bri.T(), int64(950100), resp.Data[2].EndBalance.Value)
+
+	// cancellation aborts the page in flight and returns entries collected so far
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	pages = nil
+	resp, err = coreGateway.AccountStatement(ctx, "token", req)
+	assert.Contains(bri.T(), err.Error(), context.Canceled.Error())
+	assert.Equal(bri.T(), 1, pages[0])
+	assert.Equal(bri.T(), 2, len(resp.Data))
+}
+
+func (bri *BriSanguTestSuite) TestCallFailoverBaseURLs() {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"status":true,"responseCode":"00"}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = "http://127.0.0.1:1"
+	bri.client.FailoverBaseURLs = []string{"http://127.0.0.1:1/", server.URL}
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	var resp VaResponse
+	err := coreGateway.Call("POST", VA_PATH, nil, strings.NewReader(`{"brivaNo":"77777"}`), &resp, nil)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "00", resp.ResponseCode)
+	assert.Equal(bri.T(), []string{`{"brivaNo":"77777"}`}, bodies)
+
+	bri.client.FailoverBaseURLs = []string{"http://127.0.0.1:1"}
+	coreGateway.Client = bri.client
+	err = coreGateway.Call("POST", VA_PATH, nil, strings.NewReader(`{"brivaNo":"77777"}`), &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+}
+
+func (bri *BriSanguTestSuite) TestCallFailoverNotAfterSent() {
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// request is accepted, but no response is sent before the client times out
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer hung.Close()
+
+	var failoverCalls int32
+	failover := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failoverCalls, 1)
+		w.Write([]byte(`{"status":true,"responseCode":"00"}`))
+	}))
+	defer failover.Close()
+
+	bri.client.BaseUrl = hung.URL
+	bri.client.FailoverBaseURLs = []string{failover.URL}
+	bri.client.Timeout = 50 * time.Millisecond
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	var resp VaResponse
+	err := coreGateway.Call("POST", VA_PATH, nil, strings.NewReader(`{"brivaNo":"77777"}`), &resp, nil)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+
+	var callErr *Error
+	bri.Require().True(errors.As(err, &callErr))
+	assert.False(bri.T(), callErr.IsRetrySafe())
+	assert.Equal(bri.T(), int32(0), atomic.LoadInt32(&failoverCalls))
+}
+
+func (bri *BriSanguTestSuite) TestStaticBRIVA() {
+	body, err := json.Marshal(CreateVaRequest{BrivaNo: "77777", CustCode: "1"})
+	assert.Equal(bri.T(), nil, err)
+	assert.NotContains(bri.T(), string(body), "accountType")
+
+	bri.client.DryRun = true
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	_, err = coreGateway.CreateStaticBRIVA("token", CreateVaRequest{BrivaNo: "77777", CustCode: "1"})
+	var dryRun *DryRunError
+	assert.True(bri.T(), errors.As(err, &dryRun))
+
+	sent, _ := ioutil.ReadAll(dryRun.Request.Body)
+	assert.Contains(bri.T(), string(sent), `"accountType":"STATIC"`)
+
+	report := VaReportResponse{
+		Data: []VaReportData{
+			{BrivaNo: "77777", CustCode: "1", Amount: NewMoney(1000000, "")},
+			{BrivaNo: "77777", CustCode: "2", Amount: NewMoney(2000000, "")},
+			{BrivaNo: "77777", CustCode: "1", Amount: NewMoney(3000000, "")},
+		},
+	}
+	payments := report.PaymentsByVA()
+	assert.Equal(bri.T(), 2, len(payments["777771"]))
+	assert.Equal(bri.T(), 1, len(payments["777772"]))
+}
+
+func (bri *BriSanguTestSuite) TestSimulatePaymentBRIVA() {
+	paid := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == VA_SIMULATE_PAYMENT_PATH:
+			paid = true
+			w.Write([]byte(`{"status":true,"responseCode":"00","data":{"brivaNo":"77777","custCode":"1","amount":"10000"}}`))
+		case strings.HasPrefix(r.URL.Path, VA_REPORT_PATH):
+			if !paid {
+				w.Write([]byte(`{"status":false,"responseCode":"41"}`))
+				return
+			}
+			w.Write([]byte(`{"status":true,"responseCode":"00","data":[{"brivaNo":"77777","custCode":"1","amount":"10000"}]}`))
+		default:
+			w.Write([]byte(`{"status":true,"responseCode":"00","data":{"brivaNo":"77777","custCode":"1","amount":"10000"}}`))
+		}
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	_, err := coreGateway.CreateVA("token", CreateVaRequest{InstitutionCode: "J104408", BrivaNo: "77777", CustCode: "1", Amount: "10000"})
+	assert.Equal(bri.T(), nil, err)
+
+	reportReq := GetReportVaRequest{InstitutionCode: "J104408", BrivaNo: "77777", StartDate: "20211102", EndDate: "20211102"}
+	report, err := coreGateway.GetReportVA("token", reportReq)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), 0, len(report.Data))
+
+	resp, err := coreGateway.SimulatePaymentBRIVA("token", SimulatePaymentRequest{InstitutionCode: "J104408", BrivaNo: "77777", CustCode: "1", Amount: "10000"})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), VA_RESP_CODE_SUCCESS, resp.ResponseCode)
+
+	report, err = coreGateway.GetReportVA("token", reportReq)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), 1, len(report.PaymentsByVA()["777771"]))
+
+	coreGateway.Client.IsProduction = true
+	_, err = coreGateway.SimulatePaymentBRIVA("token", SimulatePaymentRequest{})
+	assert.Equal(bri.T(), ErrSandboxOnly, err)
+}
+
+func (bri *BriSanguTestSuite) TestRetryResign() {
+	var timestamps []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get("BRI-Timestamp")
+		timestamps = append(timestamps, timestamp)
+
+		expected := generateSignature(VA_PATH, http.MethodPost, "Bearer token", timestamp, string(body), bri.client.ClientSecret)
+		assert.Equal(bri.T(), expected, r.Header.Get("BRI-Signature"))
+
+		if len(timestamps) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":true,"responseCode":"00","data":{"brivaNo":"77777","custCode":"1"}}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2021, 11, 2, 13, 0, 0, 0, time.UTC)
+	bri.client.BaseUrl = server.URL
+	bri.client.Now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	resp, err := coreGateway.CreateVA("token", CreateVaRequest{InstitutionCode: "J104408", BrivaNo: "77777", CustCode: "1", Amount: "10000"})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), VA_RESP_CODE_SUCCESS, resp.ResponseCode)
+
+	// the retry is sent with a fresh timestamp and signature
+	assert.Equal(bri.T(), 2, len(timestamps))
+	assert.NotEqual(bri.T(), timestamps[0], timestamps[1])
+}
+
+func (bri *BriSanguTestSuite) TestGetReportVAAllPaging() {
+	var pages []string
+	lastPage := `{"status":false,"responseCode":"41","responseDescription":"Tidak ada transaksi"}`
+	var onPage func(r *http.Request) bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		assert.Equal(bri.T(), "2", r.URL.Query().Get("limit"))
+		assert.Equal(bri.T(), generateSignature(r.URL.Path+"?"+r.URL.RawQuery, http.MethodGet, "Bearer token", r.Header.Get("BRI-Timestamp"), "", bri.client.ClientSecret), r.Header.Get("BRI-Signature"))
+		if onPage != nil && onPage(r) {
+			return
+		}
+
+		switch page {
+		case "1":
+			w.Write([]byte(`{"status":true,"responseCode":"00","data":[{"custCode":"1"},{"custCode":"2"}]}`))
+		default:
+			w.Write([]byte(lastPage))
+		}
+		pages = append(pages, page)
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	req := GetReportVaRequest{InstitutionCode: "J104408", BrivaNo: "77777", StartDate: "20211101", EndDate: "20211102", Limit: 2}
+
+	// empty page after a full page ends iteration as success
+	resp, err := coreGateway.GetReportVAAll(context.Background(), "token", req)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), []string{"1", "2"}, pages)
+	assert.Equal(bri.T(), VA_RESP_CODE_SUCCESS, resp.ResponseCode)
+	assert.Equal(bri.T(), 2, len(resp.Data))
+
+	// partial page is the last page
+	pages = nil
+	lastPage = `{"status":true,"responseCode":"00","data":[{"custCode":"3"}]}`
+	resp, err = coreGateway.GetReportVAAll(context.Background(), "token", req)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), []string{"1", "2"}, pages)
+	assert.Equal(bri.T(), 3, len(resp.Data))
+	assert.Equal(bri.T(), "3", resp.Data[2].CustCode)
+
+	// cancellation aborts the page in flight and returns data collected so far
+	pages = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	onPage = func(r *http.Request) bool {
+		if r.URL.Query().Get("page") != "2" {
+			return false
+		}
+		cancel()
+		<-r.Context().Done()
+		return true
+	}
+	resp, err = coreGateway.GetReportVAAll(ctx, "token", req)
+	assert.Contains(bri.T(), err.Error(), context.Canceled.Error())
+	assert.Equal(bri.T(), []string{"1"}, pages)
+	assert.Equal(bri.T(), 2, len(resp.Data))
+}
@@ -0,0 +1,136 @@
+package bri
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+)
+
+// redactedValue replaces secrets in DebugWriter dump
+const redactedValue = "[REDACTED]"
+
+// debugRedactedHeaders are headers of which value is replaced in DebugWriter dump
+var debugRedactedHeaders = []string{
+	"Authorization",
+	"Bri-Signature",
+	"X-Bri-Signature",
+	"X-Bri-Api-Key",
+	"X-Signature",
+	"X-Client-Key",
+}
+
+// debugRedactedBody matches secret and customer fields of json and form body, e.g. client_secret of token request,
+// access_token of its response, card_token of a charge and token of a card binding
+var debugRedactedBody = regexp.MustCompile(`("?\b(?:client_secret|access_token|accessToken|otp|passcode|password|card_token|registration_token|charge_token|token|phone_number)\b"?\s*[:=]\s*"?)([^"&,}\s]*)`)
+
+// debugMaskedCardPAN matches card number field of json and form body, it is masked instead of redacted
+var debugMaskedCardPAN = regexp.MustCompile(`("?card_pan"?\s*[:=]\s*"?)([^"&,}\s]*)`)
+
+// redactBody replaces secret field values in body and masks card number using MaskCardNumber
+func redactBody(body []byte) []byte {
+	body = debugRedactedBody.ReplaceAll(body, []byte("${1}"+redactedValue))
+
+	return debugMaskedCardPAN.ReplaceAllFunc(body, func(match []byte) []byte {
+		groups := debugMaskedCardPAN.FindSubmatch(match)
+		return append(append([]byte{}, groups[1]...), MaskCardNumber(string(groups[2]))...)
+	})
+}
+
+// redactHeader returns copy of header with secret header values replaced
+func redactHeader(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for k, v := range header {
+		redacted[k] = v
+	}
+
+	for _, k := range debugRedactedHeaders {
+		if redacted.Get(k) != "" {
+			redacted.Set(k, redactedValue)
+		}
+	}
+
+	return redacted
+}
+
+// dumpRequest returns wire format of req with secrets redacted. req body is left unread, so it can still be sent.
+func dumpRequest(req *http.Request) ([]byte, error) {
+	var body []byte
+	if req.Body != nil && req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		body, err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the copy is not bound to req context, so dumping doesn't trigger its client trace
+	dump := req.Clone(context.Background())
+	dump.Header = redactHeader(req.Header)
+	if body != nil {
+		body = redactBody(body)
+		dump.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dump.ContentLength = int64(len(body))
+	}
+
+	return httputil.DumpRequestOut(dump, body != nil)
+}
+
+// dumpResponse returns wire format of res with secrets redacted. body is the response body which is already read.
+func dumpResponse(res *http.Response, body []byte) ([]byte, error) {
+	dump := *res
+	dump.Header = redactHeader(res.Header)
+
+	head, err := httputil.DumpResponse(&dump, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(head, redactBody(body)...), nil
+}
+
+// debugExchange writes request and response of a call to Client.DebugWriter in a single write, so dumps of concurrent calls don't interleave.
+// reqDump is result of dumpRequest, res is nil and err is set if the request failed.
+func (c *Client) debugExchange(reqDump []byte, res *http.Response, body []byte, err error) {
+	if c.DebugWriter == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(">>> request\n")
+	buf.Write(reqDump)
+	buf.WriteString("\n<<< response\n")
+
+	if res == nil {
+		fmt.Fprintf(&buf, "error: %v\n", err)
+	} else if resDump, dumpErr := dumpResponse(res, body); dumpErr != nil {
+		fmt.Fprintf(&buf, "cannot dump response: %v\n", dumpErr)
+	} else {
+		buf.Write(resDump)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	c.DebugWriter.Write(buf.Bytes())
+}
+
+// debugRequest dumps req if Client.DebugWriter is set
+func (c *Client) debugRequest(req *http.Request) []byte {
+	if c.DebugWriter == nil {
+		return nil
+	}
+
+	reqDump, err := dumpRequest(req)
+	if err != nil {
+		return []byte(fmt.Sprintf("cannot dump request: %v\n", err))
+	}
+
+	return reqDump
+}
@@ -0,0 +1,102 @@
+package bri
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ChargeCache deduplicates CreatePaymentChargeOTP by idempotency key. Within TTL, a charge with the same key returns
+// the first response without calling BRI again, and a concurrent charge with the same key waits for the first one.
+// It guards against double submit of the application, e.g. double click or retry loop.
+// Charge failed before it was sent to BRI, e.g. Error.IsRetrySafe or missing client config, is not cached, so it can be
+// retried with the same key. BRI error response and failure after the request was sent, e.g. timeout, are cached like
+// a response, since BRI may have processed the charge.
+type ChargeCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*chargeCacheEntry
+}
+
+// chargeCacheEntry is a charge of an idempotency key, expiresAt is zero while the charge is in flight
+type chargeCacheEntry struct {
+	done      chan struct{}
+	res       PaymentChargeResponse
+	err       error
+	evicted   bool
+	expiresAt time.Time
+}
+
+// NewChargeCache creates charge cache which keeps successful charge response for ttl
+func NewChargeCache(ttl time.Duration) *ChargeCache {
+	return &ChargeCache{
+		TTL: ttl,
+	}
+}
+
+// do returns cached response of key, or calls charge and caches its response unless it fails retry safe
+func (c *ChargeCache) do(key string, charge func() (PaymentChargeResponse, error)) (PaymentChargeResponse, error) {
+	if key == "" {
+		return charge()
+	}
+
+	c.mu.Lock()
+	c.evict(time.Now())
+	if entry, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+
+		<-entry.done
+		if entry.evicted {
+			// first charge was not sent and is removed from cache, charge again
+			return c.do(key, charge)
+		}
+		return entry.res, entry.err
+	}
+
+	entry := &chargeCacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	entry.res, entry.err = charge()
+
+	c.mu.Lock()
+	if !chargeSent(entry.err) {
+		entry.evicted = true
+		delete(c.entries, key)
+	} else {
+		entry.expiresAt = time.Now().Add(c.TTL)
+	}
+	c.mu.Unlock()
+	close(entry.done)
+
+	return entry.res, entry.err
+}
+
+// chargeSent reports whether err is a BRI response or a failure after the charge was sent, so BRI may have processed it
+func chargeSent(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var briErr *BRIError
+	if errors.As(err, &briErr) {
+		return true
+	}
+
+	var callErr *Error
+	return errors.As(err, &callErr) && !callErr.IsRetrySafe()
+}
+
+// evict removes expired entries, in flight entries are kept
+func (c *ChargeCache) evict(now time.Time) {
+	if c.entries == nil {
+		c.entries = make(map[string]*chargeCacheEntry)
+	}
+
+	for key, entry := range c.entries {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
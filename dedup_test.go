@@ -0,0 +1,110 @@
+package bri
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestChargeCache() {
+	cache := NewChargeCache(50 * time.Millisecond)
+
+	var calls int32
+	charge := func() (PaymentChargeResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+
+		var res PaymentChargeResponse
+		res.Body.PaymentID = "payment-1"
+		return res, nil
+	}
+
+	// concurrent charge with the same key waits for the first one
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cache.do("key-1", charge)
+			assert.Equal(bri.T(), nil, err)
+			assert.Equal(bri.T(), "payment-1", res.Body.PaymentID)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(bri.T(), int32(1), atomic.LoadInt32(&calls))
+
+	cache.do("key-2", charge)
+	assert.Equal(bri.T(), int32(2), atomic.LoadInt32(&calls))
+
+	// expired
+	time.Sleep(60 * time.Millisecond)
+	cache.do("key-1", charge)
+	assert.Equal(bri.T(), int32(3), atomic.LoadInt32(&calls))
+
+	// charge failed before it was sent is not cached
+	_, err := cache.do("key-3", func() (PaymentChargeResponse, error) {
+		return PaymentChargeResponse{}, &Error{Err: ErrConnection, RetrySafe: true}
+	})
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	cache.do("key-3", charge)
+	assert.Equal(bri.T(), int32(4), atomic.LoadInt32(&calls))
+
+	// charge failed after it was sent may have been processed by BRI, its error is cached
+	_, err = cache.do("key-4", func() (PaymentChargeResponse, error) {
+		return PaymentChargeResponse{}, &Error{Err: ErrConnection}
+	})
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	_, err = cache.do("key-4", charge)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+	assert.Equal(bri.T(), int32(4), atomic.LoadInt32(&calls))
+
+	// BRI error response is cached too
+	cache.do("key-5", func() (PaymentChargeResponse, error) {
+		return PaymentChargeResponse{}, &BRIError{Code: "0102"}
+	})
+	_, err = cache.do("key-5", charge)
+	assert.Equal(bri.T(), &BRIError{Code: "0102"}, err)
+	assert.Equal(bri.T(), int32(4), atomic.LoadInt32(&calls))
+
+	// missing client config is never sent, the charge is retried once it is fixed
+	for _, configErr := range []error{ErrMissingDirectDebitBaseURL, ErrMissingClientSecret, ErrMissingAPIKey} {
+		_, err = cache.do("key-6", func() (PaymentChargeResponse, error) {
+			return PaymentChargeResponse{}, configErr
+		})
+		assert.Equal(bri.T(), configErr, err)
+	}
+	_, err = cache.do("key-6", charge)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int32(5), atomic.LoadInt32(&calls))
+}
+
+func (bri *BriSanguTestSuite) TestCreatePaymentChargeOTPChargeCache() {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"body":{"payment_id":"payment-1","status":"PENDING_USER_VERIFICATION"}}`))
+	}))
+	defer server.Close()
+
+	bri.client.DirectDebitBaseURL = server.URL
+	bri.client.ChargeCache = NewChargeCache(time.Minute)
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	req := NewPaymentChargeOTPRequest("card_.eyJ", NewMoney(1000000, CurrencyIDR), "order-1")
+	for i := 0; i < 2; i++ {
+		resp, err := coreGateway.CreatePaymentChargeOTP("token", "key-1", req)
+		assert.Equal(bri.T(), nil, err)
+		assert.Equal(bri.T(), "payment-1", resp.Body.PaymentID)
+	}
+	assert.Equal(bri.T(), int32(1), atomic.LoadInt32(&calls))
+
+	coreGateway.CreatePaymentChargeOTP("token", "key-2", req)
+	assert.Equal(bri.T(), int32(2), atomic.LoadInt32(&calls))
+}
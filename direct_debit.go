@@ -1,68 +1,114 @@
 package bri
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
 )
 
-// production path user "rt-" prefix
-var (
+// production path user "rt-" prefix, sandbox path is resolved by Client.directDebitPath
+const (
 	urlCreateCardTokenOTP           = "/v1/rt-directdebit/tokens"          // POST
 	urlCreateCardTokenOTPVerify     = "/v1/rt-directdebit/tokens"          // PATCH
 	urlDeleteCardToken              = "/v1/rt-directdebit/tokens"          // DELETE
 	urlCreatePaymentChargeOTP       = "/v1/rt-directdebit/charges"         // POST
 	urlCreatePaymentChargeOTPVerify = "/v1/rt-directdebit/charges/verify"  // POST
-	urlChargeDetail                 = "/v1/rt-directdebit/charges/inquiry" // POST
+	urlChargeDetail                 = "/v1/rt-directdebit/charges/inquiry" // POST, full charge record
 	urlRefundDirectDebit            = "/v1/rt-directdebit/refunds"         // POST
+	urlCardTokenStatus              = "/v1/rt-directdebit/tokens/inquiry"  // POST
+	urlResendCardTokenOTP           = "/v1/rt-directdebit/tokens/otp"      // POST
+	urlCancelCharge                 = "/v1/rt-directdebit/charges/cancel"  // POST
+	urlListCardTokens               = "/v1/rt-directdebit/tokens/list"     // POST
+	urlRefundStatus                 = "/v1/rt-directdebit/refunds/inquiry" // POST
+	urlRegisterRecurring            = "/v1/rt-directdebit/recurring"       // POST
+	urlUnregisterRecurring          = "/v1/rt-directdebit/recurring"       // DELETE
 )
 
-// CreateCardTokenOTP verifies that the information provided by the customers matches the bank data.
-// This API will alse send OTP code confirmation to user if user phonenumber is valid.
-func (g *CoreGateway) CreateCardTokenOTP(token string, req CardTokenOTPRequest) (res CardTokenOTPResponse, err error) {
-	req.Body.OtpBriStatus = "YES"
-
-	token = "Bearer " + token
-	method := http.MethodPost
-	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlCreateCardTokenOTP, method, token, timestamp, string(body), g.Client.ClientSecret)
-
+// directDebitHeaders returns headers of signed direct debit request. API key is only sent outside production.
+func (g *CoreGateway) directDebitHeaders(token, timestamp, signature, contentType string) map[string]string {
 	headers := map[string]string{
 		"Authorization":   token,
 		"BRI-Timestamp":   timestamp,
 		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
+	}
+
+	if contentType != "" {
+		headers["Content-Type"] = contentType
 	}
 
 	if !g.Client.IsProduction {
 		headers["X-BRI-Api-Key"] = g.Client.APIKey
 	}
 
-	err = g.CallDirectDebit(method, urlCreateCardTokenOTP, headers, strings.NewReader(string(body)), &res)
+	return headers
+}
+
+// otpError returns *OTPError if BRI rejects OTP verification because the OTP is expired or wrong
+func otpError(res ErrorResponse) error {
+	switch res.Code() {
+	case StatusCodeExpiredOTP:
+		return &OTPError{Err: ErrOTPExpired, RemainingAttempts: res.Error.RemainingAttempts}
+	case StatusCodeInvalidOTP:
+		return &OTPError{Err: ErrInvalidOTP, RemainingAttempts: res.Error.RemainingAttempts}
+	}
+
+	return nil
+}
+
+// CreateCardTokenOTP verifies that the information provided by the customers matches the bank data.
+// This API will alse send OTP code confirmation to user if user phonenumber is valid.
+// OtpBriStatus defaults to "YES" if it is not set, set it to "NO" for binding flow without BRI OTP.
+// Phone number is normalized using NormalizePhoneID, ErrInvalidPhoneNumber is returned without calling BRI if it is invalid.
+func (g *CoreGateway) CreateCardTokenOTP(token string, req CardTokenOTPRequest) (res CardTokenOTPResponse, err error) {
+	return g.createCardTokenOTP(context.Background(), token, req)
+}
+
+// createCardTokenOTP is CreateCardTokenOTP bound to ctx
+func (g *CoreGateway) createCardTokenOTP(ctx context.Context, token string, req CardTokenOTPRequest) (res CardTokenOTPResponse, err error) {
+	if req.Body.OtpBriStatus == "" {
+		req.Body.OtpBriStatus = "YES"
+	}
+
+	// malformed phone number makes OTP undelivered, reject it before wasting a binding attempt
+	if req.Body.PhoneNumber, err = NormalizePhoneID(req.Body.PhoneNumber); err != nil {
+		return
+	}
+
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlCreateCardTokenOTP)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.callDirectDebit(ctx, method, path, headers, strings.NewReader(string(body)), &res)
 	return
 }
 
 // CreateCardTokenOTPVerify is used to verify OTP from create card token OTP url.
 func (g *CoreGateway) CreateCardTokenOTPVerify(token string, req CardTokenOTPVerifyRequest) (res CardTokenOTPVerifyResponse, err error) {
+	return g.createCardTokenOTPVerify(context.Background(), token, req)
+}
+
+// createCardTokenOTPVerify is CreateCardTokenOTPVerify bound to ctx
+func (g *CoreGateway) createCardTokenOTPVerify(ctx context.Context, token string, req CardTokenOTPVerifyRequest) (res CardTokenOTPVerifyResponse, err error) {
 	token = "Bearer " + token
 	method := http.MethodPatch
+	path := g.Client.directDebitPath(urlCreateCardTokenOTPVerify)
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlCreateCardTokenOTPVerify, method, token, timestamp, string(body), g.Client.ClientSecret)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
-	}
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
 
-	if !g.Client.IsProduction {
-		headers["X-BRI-Api-Key"] = g.Client.APIKey
+	err = g.callDirectDebit(ctx, method, path, headers, strings.NewReader(string(body)), &res)
+	if otpErr := otpError(res.ErrorResponse); otpErr != nil {
+		err = otpErr
 	}
 
-	err = g.CallDirectDebit(method, urlCreateCardTokenOTPVerify, headers, strings.NewReader(string(body)), &res)
 	return
 }
 
@@ -70,116 +116,239 @@ func (g *CoreGateway) CreateCardTokenOTPVerify(token string, req CardTokenOTPVer
 func (g *CoreGateway) DeleteCardToken(token string, req DeleteCardTokenRequest) (res DeleteCardTokenResponse, err error) {
 	token = "Bearer " + token
 	method := http.MethodDelete
+	path := g.Client.directDebitPath(urlDeleteCardToken)
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlDeleteCardToken, method, token, timestamp, string(body), g.Client.ClientSecret)
-
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
-	}
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
 
-	if !g.Client.IsProduction {
-		headers["X-BRI-Api-Key"] = g.Client.APIKey
-	}
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
 
-	err = g.CallDirectDebit(method, urlDeleteCardToken, headers, strings.NewReader(string(body)), &res)
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
 	return
 }
 
 // CreatePaymentChargeOTP is used for payment of direct link transactions based on card number via card_token acquired from binding process (create a card token).
 // This API will alse send OTP code confirmation to user if user phonenumber is valid.
+// If Client.ChargeCache is set, charge retried with the same idempotencyKey within its TTL returns the first response.
 func (g *CoreGateway) CreatePaymentChargeOTP(token, idempotencyKey string, req PaymentChargeOTPRequest) (res PaymentChargeResponse, err error) {
-	token = "Bearer " + token
-	method := http.MethodPost
-	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlCreatePaymentChargeOTP, method, token, timestamp, string(body), g.Client.ClientSecret)
+	return g.createPaymentCharge(context.Background(), token, idempotencyKey, req)
+}
 
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
-		"Idempotency-Key": idempotencyKey,
+// createPaymentCharge is CreatePaymentChargeOTP bound to ctx
+func (g *CoreGateway) createPaymentCharge(ctx context.Context, token, idempotencyKey string, req PaymentChargeOTPRequest) (res PaymentChargeResponse, err error) {
+	if err = validateIDRAmount(req.Body.Amount, req.Body.Currency); err != nil {
+		return
 	}
 
-	if !g.Client.IsProduction {
-		headers["X-BRI-Api-Key"] = g.Client.APIKey
+	if g.Client.ChargeCache != nil {
+		return g.Client.ChargeCache.do(idempotencyKey, func() (PaymentChargeResponse, error) {
+			return g.createPaymentChargeOTP(ctx, token, idempotencyKey, req)
+		})
 	}
 
-	err = g.CallDirectDebit(method, urlCreatePaymentChargeOTP, headers, strings.NewReader(string(body)), &res)
+	return g.createPaymentChargeOTP(ctx, token, idempotencyKey, req)
+}
+
+func (g *CoreGateway) createPaymentChargeOTP(ctx context.Context, token, idempotencyKey string, req PaymentChargeOTPRequest) (res PaymentChargeResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlCreatePaymentChargeOTP)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["Idempotency-Key"] = idempotencyKey
+
+	err = g.callDirectDebit(ctx, method, path, headers, strings.NewReader(string(body)), &res)
 	return
 }
 
 // CreatePaymentChargeOTPVerify is used to verify OTP from create payment charge OTP url.
 func (g *CoreGateway) CreatePaymentChargeOTPVerify(token string, req PaymentChargeOTPVerifyRequest) (res PaymentChargeResponse, err error) {
+	return g.createPaymentChargeOTPVerify(context.Background(), token, req)
+}
+
+// createPaymentChargeOTPVerify is CreatePaymentChargeOTPVerify bound to ctx
+func (g *CoreGateway) createPaymentChargeOTPVerify(ctx context.Context, token string, req PaymentChargeOTPVerifyRequest) (res PaymentChargeResponse, err error) {
 	token = "Bearer " + token
 	method := http.MethodPost
+	path := g.Client.directDebitPath(urlCreatePaymentChargeOTPVerify)
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlCreatePaymentChargeOTPVerify, method, token, timestamp, string(body), g.Client.ClientSecret)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
-	}
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
 
-	if !g.Client.IsProduction {
-		headers["X-BRI-Api-Key"] = g.Client.APIKey
+	err = g.callDirectDebit(ctx, method, path, headers, strings.NewReader(string(body)), &res)
+	if otpErr := otpError(res.ErrorResponse); otpErr != nil {
+		err = otpErr
 	}
 
-	err = g.CallDirectDebit(method, urlCreatePaymentChargeOTPVerify, headers, strings.NewReader(string(body)), &res)
 	return
 }
 
-// GetChargeDetail returns charge direct debit charge detail
+// GetChargeDetail returns charge direct debit charge detail.
+// It maps to BRI charge inquiry (/charges/inquiry), the only charge inquiry BRI direct debit exposes, which returns the full charge record
+// including payment status and refund history. There is no separate lightweight status endpoint, use Body.PaymentStatus for the charge state.
 func (g *CoreGateway) GetChargeDetail(token string, req ChargeDetailRequest) (res ChargeDetailResponse, err error) {
 	token = "Bearer " + token
 	method := http.MethodPost
+	path := g.Client.directDebitPath(urlChargeDetail)
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlChargeDetail, method, token, timestamp, string(body), g.Client.ClientSecret)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	return
+}
+
+// RefundDirectDebit will refund direct debit transaction
+func (g *CoreGateway) RefundDirectDebit(token string, idempotencyKey string, req RefundRequest) (res RefundResponse, err error) {
+	if err = validateIDRAmount(req.Body.Amount, req.Body.Currency); err != nil {
+		return
 	}
 
-	if !g.Client.IsProduction {
-		headers["X-BRI-Api-Key"] = g.Client.APIKey
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlRefundDirectDebit)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["Idempotency-Key"] = idempotencyKey
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	return
+}
+
+// CardTokenStatus inquires binding status of a card token, so expired or revoked card can be bound again before it is charged.
+// Binding status is one of CardTokenStatusActive, CardTokenStatusExpired or CardTokenStatusRevoked.
+func (g *CoreGateway) CardTokenStatus(token string, req CardTokenStatusRequest) (res CardTokenStatusResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlCardTokenStatus)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	return
+}
+
+// ResendCardTokenOTP re-sends OTP of an in-progress card token binding identified by registration token.
+// ErrTooManyOTPResend is returned if BRI rejects the request because OTP has been resent too many times.
+func (g *CoreGateway) ResendCardTokenOTP(token string, req ResendOTPRequest) (res ResendOTPResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlResendCardTokenOTP)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	if res.StatusCode == http.StatusTooManyRequests || res.Error.Code == DirectDebitErrCodeOTPResendLimit {
+		err = ErrTooManyOTPResend
 	}
 
-	err = g.CallDirectDebit(method, urlChargeDetail, headers, strings.NewReader(string(body)), &res)
 	return
 }
 
-// RefundDirectDebit will refund direct debit transaction
-func (g *CoreGateway) RefundDirectDebit(token string, idempotencyKey string, req RefundRequest) (res RefundResponse, err error) {
+// CancelCharge cancels a pending direct debit charge and returns its final payment status.
+// ErrChargeAlreadySettled is returned if the charge is already settled, such charge has to be voided using RefundDirectDebit.
+func (g *CoreGateway) CancelCharge(token string, req CancelChargeRequest) (res CancelChargeResponse, err error) {
 	token = "Bearer " + token
 	method := http.MethodPost
+	path := g.Client.directDebitPath(urlCancelCharge)
 	body, err := json.Marshal(req)
-	timestamp := getTimestamp(BRI_TIME_FORMAT)
-	signature := generateSignature(urlRefundDirectDebit, method, token, timestamp, string(body), g.Client.ClientSecret)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
 
-	headers := map[string]string{
-		"Authorization":   token,
-		"BRI-Timestamp":   timestamp,
-		"X-BRI-Signature": signature,
-		"Content-Type":    "application/json",
-		"Idempotency-Key": idempotencyKey,
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	if res.Error.Code == DirectDebitErrCodeChargeSettled {
+		err = ErrChargeAlreadySettled
 	}
 
-	if !g.Client.IsProduction {
-		headers["X-BRI-Api-Key"] = g.Client.APIKey
+	return
+}
+
+// ListCardTokens returns active card token bindings of a customer with masked card number and expiry.
+// Use DeleteCardToken to remove a binding.
+func (g *CoreGateway) ListCardTokens(token string, req ListCardTokensRequest) (res ListCardTokensResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlListCardTokens)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	return
+}
+
+// RefundStatus inquires refund by its refund id. Refund settles asynchronously, Body.RefundStatus is
+// StatusCodePending while it is processed and StatusCodePaymentSuccess or StatusCodeFailed once it is settled.
+// ErrRefundNotFound is returned if BRI doesn't know the refund id.
+func (g *CoreGateway) RefundStatus(token string, req RefundStatusRequest) (res RefundStatusResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlRefundStatus)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	if res.Error.Code == DirectDebitErrCodeRefundNotFound {
+		err = ErrRefundNotFound
 	}
 
-	err = g.CallDirectDebit(method, urlRefundDirectDebit, headers, strings.NewReader(string(body)), &res)
+	return
+}
+
+// RegisterRecurring registers recurring (auto debit) schedule of a bound card. BRI charges the card itself
+// on every schedule, so no CreatePaymentChargeOTP call is needed for each payment.
+func (g *CoreGateway) RegisterRecurring(token string, req RecurringRegisterRequest) (res RecurringRegisterResponse, err error) {
+	if err = validateIDRAmount(req.Body.Amount, req.Body.Currency); err != nil {
+		return
+	}
+
+	token = "Bearer " + token
+	method := http.MethodPost
+	path := g.Client.directDebitPath(urlRegisterRecurring)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
+	return
+}
+
+// UnregisterRecurring stops recurring schedule registered by RegisterRecurring
+func (g *CoreGateway) UnregisterRecurring(token string, req RecurringUnregisterRequest) (res RecurringUnregisterResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodDelete
+	path := g.Client.directDebitPath(urlUnregisterRecurring)
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(path, method, token, timestamp, string(body))
+
+	headers := g.directDebitHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.CallDirectDebit(method, path, headers, strings.NewReader(string(body)), &res)
 	return
 }
@@ -0,0 +1,152 @@
+package bri
+
+import (
+	"context"
+	"time"
+)
+
+// OTPFunc returns OTP entered by the customer for a charge which requires OTP verification.
+// ctx is the operation context, return its error if the customer doesn't enter OTP before it is done.
+type OTPFunc func(ctx context.Context, charge PaymentChargeResponse) (passcode string, err error)
+
+// stepContext returns context of one of the remaining steps of an operation bound to ctx.
+// If ctx has a deadline, the step gets an equal share of the remaining time, so a slow step leaves time for the next ones.
+// The last step gets all of the remaining time.
+func stepContext(ctx context.Context, remainingSteps int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || remainingSteps <= 1 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(remainingSteps))
+}
+
+// ChargeWithOTP charges a bound card and verifies the charge with OTP from otp, spanning a single ctx deadline.
+// Access token (see AccessToken), charge and verification each get a share of the remaining time of ctx,
+// and the flow stops as soon as ctx is done. OTP entry of the customer is bounded by ctx only.
+// If charge doesn't require OTP, the charge response is returned without calling otp.
+// If it fails after the charge is created, check the charge using GetChargeDetail before charging again.
+func (g *CoreGateway) ChargeWithOTP(ctx context.Context, idempotencyKey string, req PaymentChargeOTPRequest, otp OTPFunc) (res PaymentChargeResponse, err error) {
+	stepCtx, cancel := stepContext(ctx, 3)
+	token, err := g.AccessToken(stepCtx)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	stepCtx, cancel = stepContext(ctx, 2)
+	res, err = g.createPaymentCharge(stepCtx, token, idempotencyKey, req)
+	cancel()
+	if err != nil || !res.RequiresOTP() {
+		return
+	}
+
+	passcode, err := otp(ctx, res)
+	if err != nil {
+		return
+	}
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return g.createPaymentChargeOTPVerify(ctx, token, NewPaymentChargeOTPVerifyRequest(req.Body.CardToken, res.Body.ChargeToken, passcode))
+}
+
+// ChargeWithStoredToken charges amount to a card bound earlier, see ChargeWithOTP.
+// BRI sends OTP to the customer, which is requested from otp.
+func (g *CoreGateway) ChargeWithStoredToken(ctx context.Context, cardToken, idempotencyKey string, amount Money, remarks string, otp OTPFunc) (PaymentChargeResponse, error) {
+	return g.ChargeWithOTP(ctx, idempotencyKey, NewPaymentChargeOTPRequest(cardToken, amount, remarks), otp)
+}
+
+// BindingOTPFunc returns OTP entered by the customer for a card binding which requires OTP verification
+type BindingOTPFunc func(ctx context.Context, binding CardTokenOTPResponse) (passcode string, err error)
+
+// BindingFlow binds a new card and charges it, holding the references between the steps:
+// Start sends binding OTP, Verify verifies it and stores the card token, then Charge charges the bound card.
+// Bind runs Start and Verify with OTP from a callback. Access token is taken from AccessToken.
+// A flow is used by a single customer and is not safe for concurrent use. CardToken should be persisted to charge the card later,
+// see ChargeWithStoredToken.
+type BindingFlow struct {
+	gateway *CoreGateway
+
+	// RegistrationToken is set by Start and verified by Verify
+	RegistrationToken string
+	// CardToken is set by Verify
+	CardToken string
+}
+
+// NewBindingFlow creates BindingFlow of a new card binding
+func (g *CoreGateway) NewBindingFlow() *BindingFlow {
+	return &BindingFlow{gateway: g}
+}
+
+// Start sends binding OTP to the customer and stores registration token, see CreateCardTokenOTP
+func (f *BindingFlow) Start(ctx context.Context, req CardTokenOTPRequest) (res CardTokenOTPResponse, err error) {
+	stepCtx, cancel := stepContext(ctx, 2)
+	token, err := f.gateway.AccessToken(stepCtx)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	res, err = f.gateway.createCardTokenOTP(ctx, token, req)
+	if err != nil {
+		return
+	}
+
+	f.RegistrationToken = res.Body.Token
+	return
+}
+
+// Verify verifies binding OTP entered by the customer and stores card token, see CreateCardTokenOTPVerify.
+// Wrong or expired OTP returns *OTPError, Verify can be called again with a new OTP.
+func (f *BindingFlow) Verify(ctx context.Context, passcode string) (res CardTokenOTPVerifyResponse, err error) {
+	if f.RegistrationToken == "" {
+		err = ErrBindingNotStarted
+		return
+	}
+
+	stepCtx, cancel := stepContext(ctx, 2)
+	token, err := f.gateway.AccessToken(stepCtx)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	res, err = f.gateway.createCardTokenOTPVerify(ctx, token, NewCardTokenOTPVerifyRequest(f.RegistrationToken, passcode))
+	if err != nil {
+		return
+	}
+
+	f.CardToken = res.Body.CardToken
+	return
+}
+
+// Bind starts the binding and verifies it with OTP from otp
+func (f *BindingFlow) Bind(ctx context.Context, req CardTokenOTPRequest, otp BindingOTPFunc) (res CardTokenOTPVerifyResponse, err error) {
+	binding, err := f.Start(ctx, req)
+	if err != nil {
+		return
+	}
+
+	passcode, err := otp(ctx, binding)
+	if err != nil {
+		return
+	}
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return f.Verify(ctx, passcode)
+}
+
+// Charge charges the bound card, see ChargeWithStoredToken
+func (f *BindingFlow) Charge(ctx context.Context, idempotencyKey string, amount Money, remarks string, otp OTPFunc) (PaymentChargeResponse, error) {
+	if f.CardToken == "" {
+		return PaymentChargeResponse{}, ErrBindingNotVerified
+	}
+
+	return f.gateway.ChargeWithStoredToken(ctx, f.CardToken, idempotencyKey, amount, remarks, otp)
+}
@@ -0,0 +1,267 @@
+package bri
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordedRequest is request received by mock BRI server
+type recordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+func (bri *BriSanguTestSuite) TestDirectDebitFlowMocked() {
+	responses := map[string]string{
+		"POST /sandbox/v1/directdebit/tokens":         `{"body":{"status":"PENDING_USER_VERIFICATION","token":"reg_token"}}`,
+		"PATCH /sandbox/v1/directdebit/tokens":        `{"body":{"status":"0000","card_token":"card_token","last4":"1111"}}`,
+		"POST /sandbox/v1/directdebit/charges":        `{"body":{"status":"PENDING_USER_VERIFICATION","charge_token":"charge_token","payment_id":"payment"}}`,
+		"POST /sandbox/v1/directdebit/charges/verify": `{"body":{"status":"0000","payment_id":"payment","payment_status":"SUCCESS","amount":"10000.00"}}`,
+	}
+
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Header: r.Header,
+			Body:   string(body),
+		})
+
+		res, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(res))
+	}))
+	defer server.Close()
+
+	bri.client.DirectDebitBaseURL = server.URL
+	bri.client.APIKey = "api_key"
+	bri.client.Now = func() time.Time {
+		return time.Date(2020, 1, 1, 0, 0, 0, 123000000, time.UTC)
+	}
+	bri.client.DirectDebitHostUseSandboxPrefix(true)
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	tokenResp, err := coreGateway.CreateCardTokenOTP("token", CardTokenOTPRequest{
+		Body: CardTokenOTPRequestData{
+			CardPan:     "5221843000000001",
+			PhoneNumber: "08123456789",
+			Email:       "user@example.com",
+		},
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.True(bri.T(), tokenResp.RequiresOTP())
+
+	verifyResp, err := coreGateway.CreateCardTokenOTPVerify("token", CardTokenOTPVerifyRequest{
+		Body: CardTokenOTPVerifyRequestData{
+			RegistrationToken: tokenResp.Body.Token,
+			Passcode:          "999999",
+		},
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "card_token", verifyResp.Body.CardToken)
+
+	chargeResp, err := coreGateway.CreatePaymentChargeOTP("token", "idempotency_key", PaymentChargeOTPRequest{
+		Body: PaymentChargeOTPRequestData{
+			CardToken:    verifyResp.Body.CardToken,
+			Amount:       NewMoney(1000000, "IDR"),
+			Currency:     "IDR",
+			Remarks:      "payment",
+			OtpBriStatus: "YES",
+		},
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.True(bri.T(), chargeResp.RequiresOTP())
+
+	chargeVerifyResp, err := coreGateway.CreatePaymentChargeOTPVerify("token", PaymentChargeOTPVerifyRequest{
+		Body: PaymentChargeOTPVerifyRequestData{
+			CardToken:   verifyResp.Body.CardToken,
+			ChargeToken: chargeResp.Body.ChargeToken,
+			Passcode:    "999999",
+		},
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, chargeVerifyResp.Body.PaymentStatus)
+
+	expectedBodies := []string{
+		`{"body":{"card_pan":"5221843000000001","phone_number":"08123456789","email":"user@example.com","otp_bri_status":"YES"}}`,
+		`{"body":{"registration_token":"reg_token","passcode":"999999"}}`,
+		`{"body":{"card_token":"card_token","amount":"10000.00","currency":"IDR","remarks":"payment","otp_bri_status":"YES","metadata":null}}`,
+		`{"body":{"card_token":"card_token","charge_token":"charge_token","passcode":"999999"}}`,
+	}
+
+	assert.Equal(bri.T(), len(expectedBodies), len(requests))
+	for i, req := range requests {
+		timestamp := req.Header.Get("BRI-Timestamp")
+		assert.Equal(bri.T(), "2020-01-01T00:00:00.123Z", timestamp)
+
+		_, err := time.Parse(BRI_TIME_FORMAT, timestamp)
+		assert.Equal(bri.T(), nil, err)
+
+		assert.Equal(bri.T(), expectedBodies[i], req.Body)
+		assert.Equal(bri.T(), "Bearer token", req.Header.Get("Authorization"))
+		assert.Equal(bri.T(), ContentTypeJSON, req.Header.Get("Content-Type"))
+		assert.Equal(bri.T(), "api_key", req.Header.Get("X-BRI-Api-Key"))
+
+		signature := generateSignature(req.Path, req.Method, "Bearer token", timestamp, req.Body, bri.client.ClientSecret)
+		assert.Equal(bri.T(), signature, req.Header.Get("X-BRI-Signature"))
+	}
+
+	assert.Equal(bri.T(), "idempotency_key", requests[2].Header.Get("Idempotency-Key"))
+}
+
+func (bri *BriSanguTestSuite) TestChargeWithOTPBudget() {
+	// delay is read by handler of a timed out request while the next case sets it
+	var chargeDelay int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/client_credential/accesstoken":
+			w.Write([]byte(`{"access_token":"token","expires_in":"3599"}`))
+		case "/sandbox/v1/directdebit/charges":
+			time.Sleep(time.Duration(atomic.LoadInt64(&chargeDelay)))
+			w.Write([]byte(`{"body":{"status":"PENDING_USER_VERIFICATION","charge_token":"charge_token","payment_id":"payment"}}`))
+		case "/sandbox/v1/directdebit/charges/verify":
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(bri.T(), `{"body":{"card_token":"card_token","charge_token":"charge_token","passcode":"999999"}}`, string(body))
+			w.Write([]byte(`{"body":{"status":"0000","payment_id":"payment","payment_status":"SUCCESS","amount":"10000.00"}}`))
+		}
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	bri.client.DirectDebitBaseURL = server.URL
+	bri.client.APIKey = "api_key"
+	bri.client.DirectDebitHostUseSandboxPrefix(true)
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	req := NewPaymentChargeOTPRequest("card_token", NewMoney(1000000, CurrencyIDR), "payment")
+	otp := func(ctx context.Context, charge PaymentChargeResponse) (string, error) {
+		assert.Equal(bri.T(), "charge_token", charge.Body.ChargeToken)
+		return "999999", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := coreGateway.ChargeWithOTP(ctx, "idempotency_key", req, otp)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, res.Body.PaymentStatus)
+
+	// slow charge only gets its share of the budget
+	atomic.StoreInt64(&chargeDelay, int64(500*time.Millisecond))
+	ctx, cancel = context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = coreGateway.ChargeWithOTP(ctx, "idempotency_key_2", req, func(ctx context.Context, charge PaymentChargeResponse) (string, error) {
+		bri.T().Error("otp must not be requested")
+		return "", nil
+	})
+	assert.NotEqual(bri.T(), nil, err)
+	assert.True(bri.T(), time.Since(start) < 300*time.Millisecond)
+
+	// flow stops when budget is exhausted while waiting for OTP
+	atomic.StoreInt64(&chargeDelay, 0)
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = coreGateway.ChargeWithOTP(ctx, "idempotency_key_3", req, func(ctx context.Context, charge PaymentChargeResponse) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.Equal(bri.T(), context.DeadlineExceeded, err)
+}
+
+func (bri *BriSanguTestSuite) TestStepContext() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stepCtx, stepCancel := stepContext(ctx, 3)
+	defer stepCancel()
+	deadline, ok := stepCtx.Deadline()
+	assert.True(bri.T(), ok)
+	assert.InDelta(bri.T(), float64(time.Second), float64(time.Until(deadline)), float64(100*time.Millisecond))
+
+	// step without parent deadline is not bounded
+	stepCtx, stepCancel = stepContext(context.Background(), 3)
+	defer stepCancel()
+	_, ok = stepCtx.Deadline()
+	assert.False(bri.T(), ok)
+}
+
+func (bri *BriSanguTestSuite) TestBindingFlow() {
+	responses := map[string]string{
+		"POST /oauth/client_credential/accesstoken":   `{"access_token":"token","expires_in":"3599"}`,
+		"POST /sandbox/v1/directdebit/tokens":         `{"body":{"status":"PENDING_USER_VERIFICATION","token":"reg_token"}}`,
+		"PATCH /sandbox/v1/directdebit/tokens":        `{"body":{"status":"0000","card_token":"card_token","last4":"1111"}}`,
+		"POST /sandbox/v1/directdebit/charges":        `{"body":{"status":"PENDING_USER_VERIFICATION","charge_token":"charge_token","payment_id":"payment"}}`,
+		"POST /sandbox/v1/directdebit/charges/verify": `{"body":{"status":"0000","payment_id":"payment","payment_status":"SUCCESS","amount":"10000.00"}}`,
+	}
+
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{Method: r.Method, Path: r.URL.Path, Header: r.Header, Body: string(body)})
+		w.Write([]byte(responses[r.Method+" "+r.URL.Path]))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	bri.client.DirectDebitBaseURL = server.URL
+	bri.client.APIKey = "api_key"
+	bri.client.DirectDebitHostUseSandboxPrefix(true)
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	ctx := context.Background()
+
+	flow := coreGateway.NewBindingFlow()
+	_, err := flow.Verify(ctx, "999999")
+	assert.Equal(bri.T(), ErrBindingNotStarted, err)
+	_, err = flow.Charge(ctx, "idempotency_key", NewMoney(1000000, CurrencyIDR), "payment", nil)
+	assert.Equal(bri.T(), ErrBindingNotVerified, err)
+
+	bound, err := flow.Bind(ctx, NewCardTokenOTPRequest("5221843000000001", "08123456789", "user@example.com"), func(ctx context.Context, binding CardTokenOTPResponse) (string, error) {
+		assert.True(bri.T(), binding.RequiresOTP())
+		return "999999", nil
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "1111", bound.Body.Last4)
+	assert.Equal(bri.T(), "reg_token", flow.RegistrationToken)
+	assert.Equal(bri.T(), "card_token", flow.CardToken)
+
+	charge, err := flow.Charge(ctx, "idempotency_key", NewMoney(1000000, CurrencyIDR), "payment", func(ctx context.Context, charge PaymentChargeResponse) (string, error) {
+		return "123456", nil
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, charge.Body.PaymentStatus)
+
+	// token is requested once and reused by every step
+	paths := make([]string, len(requests))
+	for i, req := range requests {
+		paths[i] = req.Method + " " + req.Path
+	}
+	assert.Equal(bri.T(), []string{
+		"POST /oauth/client_credential/accesstoken",
+		"POST /sandbox/v1/directdebit/tokens",
+		"PATCH /sandbox/v1/directdebit/tokens",
+		"POST /sandbox/v1/directdebit/charges",
+		"POST /sandbox/v1/directdebit/charges/verify",
+	}, paths)
+	assert.Equal(bri.T(), `{"body":{"registration_token":"reg_token","passcode":"999999"}}`, requests[2].Body)
+	assert.Equal(bri.T(), `{"body":{"card_token":"card_token","charge_token":"charge_token","passcode":"123456"}}`, requests[4].Body)
+	assert.Equal(bri.T(), "idempotency_key", requests[3].Header.Get("Idempotency-Key"))
+}
@@ -1,7 +1,13 @@
 package bri
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func (bri *BriSanguTestSuite) TestDirectDebit_01_CreateCardToken() {
@@ -22,7 +28,8 @@ func (bri *BriSanguTestSuite) TestDirectDebit_01_CreateCardToken() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.CreateCardTokenOTP(token, req)
 
-	assert.Equal(bri.T(), "PENDING_USER_VERIFICATION", resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodeOTPRequired, resp.Body.Status)
+	assert.Equal(bri.T(), true, resp.RequiresOTP())
 	assert.Equal(bri.T(), nil, err)
 
 	bri.registrationCardToken = resp.Body.Token
@@ -44,7 +51,7 @@ func (bri *BriSanguTestSuite) TestDirectDebit_02_VerifyCreateCardToken() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.CreateCardTokenOTPVerify(token, req)
 
-	assert.Equal(bri.T(), "0000", resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodeSuccess, resp.Body.Status)
 	assert.Equal(bri.T(), nil, err)
 
 	bri.cardToken = resp.Body.CardToken
@@ -60,7 +67,7 @@ func (bri *BriSanguTestSuite) TestDirectDebit_03_ChargePaymentNoOTP() {
 	req := PaymentChargeOTPRequest{
 		Body: PaymentChargeOTPRequestData{
 			CardToken:    bri.cardToken,
-			Amount:       "89000.00",
+			Amount:       NewMoney(8900000, "IDR"),
 			Currency:     "IDR",
 			Remarks:      "testing remarks from unit test",
 			OtpBriStatus: "NO",
@@ -73,12 +80,13 @@ func (bri *BriSanguTestSuite) TestDirectDebit_03_ChargePaymentNoOTP() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.CreatePaymentChargeOTP(token, idempotencyKey, req)
 
-	assert.Equal(bri.T(), "0000", resp.Body.Status)
-	assert.Equal(bri.T(), "SUCCESS", resp.Body.PaymentStatus)
+	assert.Equal(bri.T(), StatusCodeSuccess, resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, resp.Body.PaymentStatus)
+	assert.Equal(bri.T(), false, resp.RequiresOTP())
 	assert.Equal(bri.T(), nil, err)
 
 	bri.paymentID = resp.Body.PaymentID
-	bri.amount = resp.Body.Amount
+	bri.amount = NewMoney(resp.Body.Amount.Value, resp.Body.Currency)
 }
 
 func (bri *BriSanguTestSuite) TestDirectDebit_04_ChargePaymentOTP() {
@@ -91,7 +99,7 @@ func (bri *BriSanguTestSuite) TestDirectDebit_04_ChargePaymentOTP() {
 	req := PaymentChargeOTPRequest{
 		Body: PaymentChargeOTPRequestData{
 			CardToken:    bri.cardToken,
-			Amount:       "63000.00",
+			Amount:       NewMoney(6300000, "IDR"),
 			Currency:     "IDR",
 			Remarks:      "testing remarks from unit test again",
 			OtpBriStatus: "YES",
@@ -104,7 +112,8 @@ func (bri *BriSanguTestSuite) TestDirectDebit_04_ChargePaymentOTP() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.CreatePaymentChargeOTP(token, idempotencyKey, req)
 
-	assert.Equal(bri.T(), "PENDING_USER_VERIFICATION", resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodeOTPRequired, resp.Body.Status)
+	assert.Equal(bri.T(), true, resp.RequiresOTP())
 	assert.Equal(bri.T(), nil, err)
 
 	bri.chargeToken = resp.Body.ChargeToken
@@ -127,12 +136,12 @@ func (bri *BriSanguTestSuite) TestDirectDebit_05_VerifyChargePaymentOTP() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.CreatePaymentChargeOTPVerify(token, req)
 
-	assert.Equal(bri.T(), "0000", resp.Body.Status)
-	assert.Equal(bri.T(), "SUCCESS", resp.Body.PaymentStatus)
+	assert.Equal(bri.T(), StatusCodeSuccess, resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, resp.Body.PaymentStatus)
 	assert.Equal(bri.T(), nil, err)
 
 	bri.paymentID = resp.Body.PaymentID
-	bri.amount = resp.Body.Amount
+	bri.amount = NewMoney(resp.Body.Amount.Value, resp.Body.Currency)
 }
 
 func (bri *BriSanguTestSuite) TestDirectDebit_06_GetChargeDetail_Found() {
@@ -150,8 +159,8 @@ func (bri *BriSanguTestSuite) TestDirectDebit_06_GetChargeDetail_Found() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.GetChargeDetail(token, req)
 
-	assert.Equal(bri.T(), "0000", resp.Body.Status)
-	assert.Equal(bri.T(), "SUCCESS", resp.Body.PaymentStatus)
+	assert.Equal(bri.T(), StatusCodeSuccess, resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, resp.Body.PaymentStatus)
 	assert.Equal(bri.T(), bri.paymentID, resp.Body.PaymentID)
 	assert.Equal(bri.T(), nil, err)
 }
@@ -171,9 +180,11 @@ func (bri *BriSanguTestSuite) TestDirectDebit_07_GetChargeDetail_NotFound() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.GetChargeDetail(token, req)
 
+	var briErr *BRIError
 	assert.Equal(bri.T(), 400, resp.ErrorResponse.StatusCode)
 	assert.Equal(bri.T(), "0301", resp.Error.Code)
-	assert.Equal(bri.T(), nil, err)
+	bri.Require().True(errors.As(err, &briErr))
+	assert.Equal(bri.T(), "0301", briErr.Code)
 }
 
 func (bri *BriSanguTestSuite) TestDirectDebit_08_Refund() {
@@ -199,8 +210,8 @@ func (bri *BriSanguTestSuite) TestDirectDebit_08_Refund() {
 	idempotencyKey := generateSha1Timestamp("08_Refund")
 	resp, err := coreGateway.RefundDirectDebit(token, idempotencyKey, req)
 
-	assert.Equal(bri.T(), "0000", resp.Body.Status)
-	assert.Equal(bri.T(), "SUCCESS", resp.Body.RefundStatus)
+	assert.Equal(bri.T(), StatusCodeSuccess, resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodePaymentSuccess, resp.Body.RefundStatus)
 	assert.Equal(bri.T(), nil, err)
 }
 
@@ -219,6 +230,247 @@ func (bri *BriSanguTestSuite) TestDirectDebit_09_DeleteCardToken() {
 	token := tokenResp.AccessToken
 	resp, err := coreGateway.DeleteCardToken(token, req)
 
-	assert.Equal(bri.T(), "0000", resp.Body.Status)
+	assert.Equal(bri.T(), StatusCodeSuccess, resp.Body.Status)
 	assert.Equal(bri.T(), nil, err)
 }
+
+func TestPaymentChargeInstallmentBody(t *testing.T) {
+	req := PaymentChargeOTPRequest{
+		Body: PaymentChargeOTPRequestData{
+			CardToken: "card_.eyJ",
+			Amount:    NewMoney(1000000, "IDR"),
+			Currency:  "IDR",
+		},
+	}
+
+	body, err := json.Marshal(req)
+	assert.Equal(t, nil, err)
+	assert.NotContains(t, string(body), "installment")
+
+	req.Body.Installment = &Installment{
+		Tenor:    3,
+		PlanCode: "PLAN03",
+	}
+	body, err = json.Marshal(req)
+	assert.Equal(t, nil, err)
+	assert.Contains(t, string(body), `"installment":{"tenor":3,"plan_code":"PLAN03"}`)
+}
+
+func TestPaymentChargeThreeDS(t *testing.T) {
+	var resp PaymentChargeResponse
+	err := json.Unmarshal([]byte(`{"body":{"status":"PENDING_USER_VERIFICATION","payment_id":"p","three_ds_redirect_url":"https://acs/challenge","three_ds_status":"CHALLENGE"}}`), &resp)
+	assert.Equal(t, nil, err)
+	assert.True(t, resp.RequiresThreeDS())
+	assert.False(t, resp.RequiresOTP())
+
+	resp = PaymentChargeResponse{}
+	err = json.Unmarshal([]byte(`{"body":{"status":"0000","payment_status":"SUCCESS","eci":"05"}}`), &resp)
+	assert.Equal(t, nil, err)
+	assert.False(t, resp.RequiresThreeDS())
+	assert.Equal(t, "05", resp.Body.ECI)
+}
+
+func TestRequestConstructors(t *testing.T) {
+	tokenReq := NewCardTokenOTPRequest("5221843000000001", "08123456789", "user@example.com")
+	assert.Equal(t, "5221843000000001", tokenReq.Body.CardPan)
+	assert.Equal(t, "YES", tokenReq.Body.OtpBriStatus)
+
+	chargeReq := NewPaymentChargeOTPRequest("card_token", NewMoney(1000000, "IDR"), "payment")
+	assert.Equal(t, "card_token", chargeReq.Body.CardToken)
+	assert.Equal(t, int64(1000000), chargeReq.Body.Amount.Value)
+	assert.Equal(t, "IDR", chargeReq.Body.Currency)
+
+	refundReq := NewRefundRequest("card_token", "payment", NewMoney(500000, "IDR"), "reason")
+	assert.Equal(t, "payment", refundReq.Body.PaymentID)
+	assert.Equal(t, "IDR", refundReq.Body.Currency)
+
+	verifyReq := NewPaymentChargeOTPVerifyRequest("card_token", "charge_token", "999999")
+	assert.Equal(t, "charge_token", verifyReq.Body.ChargeToken)
+}
+
+func TestDirectDebitMocked(t *testing.T) {
+	runMockCases(t, []mockCase{
+		{
+			name: "OTPError",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				code := "0920"
+				if r.URL.Path == urlCreatePaymentChargeOTPVerify {
+					code = "0921"
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":{"code":"` + code + `","message":"Invalid OTP","remaining_attempts":2},"status_code":400}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				_, err := gateway.CreatePaymentChargeOTPVerify("token", PaymentChargeOTPVerifyRequest{})
+				var otpErr *OTPError
+				assert.True(t, errors.Is(err, ErrInvalidOTP))
+				assert.True(t, errors.As(err, &otpErr))
+				assert.Equal(t, 2, otpErr.RemainingAttempts)
+
+				_, err = gateway.CreateCardTokenOTPVerify("token", CardTokenOTPVerifyRequest{})
+				assert.True(t, errors.Is(err, ErrOTPExpired))
+			},
+			requests: 2,
+		},
+		{
+			name: "BRIError",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"code":"0301","message":"Payment not found"},"status_code":404}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.GetChargeDetail("token", ChargeDetailRequest{})
+				var briErr *BRIError
+				assert.True(t, errors.As(err, &briErr))
+				assert.Equal(t, 404, briErr.StatusCode)
+				assert.Equal(t, "0301", briErr.Code)
+				assert.Equal(t, "Payment not found", briErr.Message)
+				assert.Equal(t, "0301", resp.Error.Code)
+			},
+			requests: 1,
+		},
+		{
+			name: "CancelCharge",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch assertDirectDebitRequest(t, r, http.MethodPost, urlCancelCharge) {
+				case `{"body":{"payment_id":"payment","reason":"order timeout"}}`:
+					w.Write([]byte(`{"body":{"status":"0000","payment_id":"payment","payment_status":"FAILED"}}`))
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":{"code":"0315","message":"Charge already settled"},"status_code":400}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.CancelCharge("token", NewCancelChargeRequest("payment", "order timeout"))
+				assert.Equal(t, nil, err)
+				assert.Equal(t, StatusCodeFailed, resp.Body.PaymentStatus)
+
+				_, err = gateway.CancelCharge("token", NewCancelChargeRequest("settled", "order timeout"))
+				assert.Equal(t, ErrChargeAlreadySettled, err)
+			},
+			requests: 2,
+		},
+		{
+			name: "GetChargeDetail",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				body := assertDirectDebitRequest(t, r, http.MethodPost, urlChargeDetail)
+				assert.Equal(t, `{"body":{"payment_id":"payment","remarks":"","metadata":null}}`, body)
+
+				w.Write([]byte(`{"body":{"status":"0000","amount":"10000.00","currency":"IDR","payment_id":"payment","card_token":"card_token","remarks":"payment","payment_status":"SUCCESS","refund_history":[{"refund_id":"refund","amount":"5000.00","refund_status":"SUCCESS"}],"date":"2021-11-02T13:00:00+07:00"}}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.GetChargeDetail("token", NewChargeDetailRequest("payment"))
+				assert.Equal(t, nil, err)
+				assert.Equal(t, StatusCodePaymentSuccess, resp.Body.PaymentStatus)
+				assert.Equal(t, "card_token", resp.Body.CardToken)
+				assert.Equal(t, "payment", resp.Body.Remarks)
+				assert.Equal(t, "2021-11-02T13:00:00+07:00", resp.Body.Date)
+				assert.Equal(t, "refund", resp.Body.RefundHistory[0].RefundID)
+			},
+			requests: 1,
+		},
+		{
+			name: "ListCardTokens",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				body := assertDirectDebitRequest(t, r, http.MethodPost, urlListCardTokens)
+				assert.Equal(t, `{"body":{"phone_number":"08123456789","email":"user@example.com"}}`, body)
+
+				w.Write([]byte(`{"body":{"status":"0000","card_tokens":[{"card_token":"card_token_1","masked_card_pan":"522184******0001","last4":"0001","card_type":"DEBIT","expired_at":"2025-12","token_status":"ACTIVE"},{"card_token":"card_token_2","last4":"0002","token_status":"ACTIVE"}]}}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.ListCardTokens("token", NewListCardTokensRequest("08123456789", "user@example.com"))
+				assert.Equal(t, nil, err)
+				require.Equal(t, 2, len(resp.Body.CardTokens))
+				assert.Equal(t, CardTokenData{
+					CardToken:     "card_token_1",
+					MaskedCardPan: "522184******0001",
+					Last4:         "0001",
+					CardType:      "DEBIT",
+					ExpiredAt:     "2025-12",
+					TokenStatus:   "ACTIVE",
+				}, resp.Body.CardTokens[0])
+				assert.Equal(t, "card_token_2", resp.Body.CardTokens[1].CardToken)
+			},
+			requests: 1,
+		},
+		{
+			name: "RefundStatus",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch assertDirectDebitRequest(t, r, http.MethodPost, urlRefundStatus) {
+				case `{"body":{"refund_id":"refund"}}`:
+					w.Write([]byte(`{"body":{"status":"0000","refund_id":"refund","payment_id":"payment","amount":"5000.00","refund_status":"PENDING"}}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"error":{"code":"0302","message":"Refund not found"},"status_code":404}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.RefundStatus("token", NewRefundStatusRequest("refund"))
+				assert.Equal(t, nil, err)
+				assert.Equal(t, StatusCodePending, resp.Body.RefundStatus)
+				assert.Equal(t, int64(500000), resp.Body.Amount.Value)
+
+				_, err = gateway.RefundStatus("token", NewRefundStatusRequest("unknown"))
+				assert.Equal(t, ErrRefundNotFound, err)
+			},
+			requests: 2,
+		},
+		{
+			name: "Recurring",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					body := assertDirectDebitRequest(t, r, http.MethodPost, urlRegisterRecurring)
+					assert.Equal(t, `{"body":{"card_token":"card_token","amount":"10000.00","currency":"IDR","frequency":"MONTHLY","start_date":"2021-12-01","remarks":"","metadata":null}}`, body)
+					w.Write([]byte(`{"body":{"status":"0000","recurring_id":"recurring","card_token":"card_token","amount":"10000.00","currency":"IDR","frequency":"MONTHLY","start_date":"2021-12-01","next_date":"2021-12-01"}}`))
+				default:
+					body := assertDirectDebitRequest(t, r, http.MethodDelete, urlUnregisterRecurring)
+					assert.Equal(t, `{"body":{"card_token":"card_token","recurring_id":"recurring"}}`, body)
+					w.Write([]byte(`{"body":{"status":"0000","recurring_id":"recurring"}}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.RegisterRecurring("token", NewRecurringRegisterRequest("card_token", NewMoney(1000000, CurrencyIDR), RecurringFrequencyMonthly, "2021-12-01"))
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "recurring", resp.Body.RecurringID)
+				assert.Equal(t, "2021-12-01", resp.Body.NextDate)
+
+				unregisterResp, err := gateway.UnregisterRecurring("token", NewRecurringUnregisterRequest("card_token", resp.Body.RecurringID))
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "recurring", unregisterResp.Body.RecurringID)
+
+				// invalid amount is rejected before sending the request
+				_, err = gateway.RegisterRecurring("token", NewRecurringRegisterRequest("card_token", NewMoney(1000050, CurrencyIDR), RecurringFrequencyMonthly, "2021-12-01"))
+				assert.True(t, errors.Is(err, ErrInvalidAmount))
+			},
+			requests: 2,
+		},
+		{
+			name: "ResendCardTokenOTP",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch assertDirectDebitRequest(t, r, http.MethodPost, urlResendCardTokenOTP) {
+				case `{"body":{"registration_token":"reg_token"}}`:
+					w.Write([]byte(`{"body":{"status":"PENDING_USER_VERIFICATION","token":"reg_token"}}`))
+				case `{"body":{"registration_token":"throttled_token"}}`:
+					w.WriteHeader(http.StatusTooManyRequests)
+					w.Write([]byte(`{"error":{"code":"0999","message":"Too many requests"},"status_code":429}`))
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":{"code":"0923","message":"OTP resend limit reached"},"status_code":400}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.ResendCardTokenOTP("token", NewResendOTPRequest("reg_token"))
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "reg_token", resp.Body.Token)
+
+				_, err = gateway.ResendCardTokenOTP("token", NewResendOTPRequest("throttled_token"))
+				assert.Equal(t, ErrTooManyOTPResend, err)
+
+				_, err = gateway.ResendCardTokenOTP("token", NewResendOTPRequest("exhausted_token"))
+				assert.Equal(t, ErrTooManyOTPResend, err)
+			},
+			requests: 3,
+		},
+	})
+}
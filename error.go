@@ -2,8 +2,235 @@ package bri
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
 )
 
+// Error wraps error of a BRI call with its http method and url, so the failed operation can be identified.
+// Use errors.Is or errors.As to check the underlying error, e.g. errors.Is(err, ErrPendingTransaction).
+type Error struct {
+	Method string
+	URL    string
+	Err    error
+
+	// RequestID is X-Request-Id header of the request, empty if the request was not created
+	RequestID string
+
+	// RetrySafe is true if the request has not been sent to BRI, e.g. request creation error, dns failure or connection refused.
+	// It is false if BRI may have received the request, e.g. timeout after the request is sent, even if no response is received.
+	RetrySafe bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bri: %s %s: %v", e.Method, e.URL, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsRetrySafe reports whether the call can be retried without risk of BRI processing the transaction twice
+func (e *Error) IsRetrySafe() bool {
+	return e.RetrySafe
+}
+
+// notSentError wraps error which happens before request is written to BRI
+type notSentError struct {
+	Err error
+}
+
+func (e *notSentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *notSentError) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnexpectedResponse defines error if BRI responds with non json body, e.g. html maintenance page.
+// The error message contains http status and beginning of the response body.
+var ErrUnexpectedResponse = errors.New("unexpected non json response")
+
 // ErrPendingTransaction defines error if BRI response with http status 200 but html error body.
 // Transaction should be pending and need to be inquired.
 var ErrPendingTransaction = errors.New("Transaction is pending")
+
+// ErrMissingPrivateKey defines error if SNAP BI request need to be signed but Client.PrivateKey is not set.
+var ErrMissingPrivateKey = errors.New("private key is required for SNAP BI signature")
+
+// ErrMissingPartnerID defines error if SNAP BI transactional request is sent but Client.PartnerID is not set.
+var ErrMissingPartnerID = errors.New("partner id is required for SNAP BI request")
+
+// ErrMissingExternalID defines error if SNAP BI transactional request is sent without X-EXTERNAL-ID idempotency key.
+var ErrMissingExternalID = errors.New("external id is required for SNAP BI request")
+
+// ErrMissingIdempotencyKey defines error if a charge of BatchCharge is sent without idempotency key.
+var ErrMissingIdempotencyKey = errors.New("idempotency key is required for payment charge")
+
+// SnapError is returned by SNAP BI transactional call if BRI responds with non success (non 2xx) responseCode,
+// e.g. "4001702" invalid mandatory field. The response is still decoded, so its other fields are available.
+type SnapError struct {
+	ResponseCode    string
+	ResponseMessage string
+}
+
+func (e *SnapError) Error() string {
+	return fmt.Sprintf("snap error %s: %s", e.ResponseCode, e.ResponseMessage)
+}
+
+// HTTPStatus returns http status part of ResponseCode, e.g. 400 for "4001702". It is 0 if ResponseCode is malformed.
+func (e *SnapError) HTTPStatus() int {
+	if len(e.ResponseCode) < 3 {
+		return 0
+	}
+
+	status, err := strconv.Atoi(e.ResponseCode[:3])
+	if err != nil {
+		return 0
+	}
+	return status
+}
+
+// ErrInvalidPrivateKey defines error if private key PEM can't be parsed as RSA private key.
+var ErrInvalidPrivateKey = errors.New("invalid RSA private key")
+
+// ErrInvalidAccountNumber defines error if BRI rejects the requested account number.
+// This usually means the account number configuration is wrong.
+var ErrInvalidAccountNumber = errors.New("invalid account number")
+
+// ErrAccountNotFound defines error if BRI can't find the inquired beneficiary account.
+var ErrAccountNotFound = errors.New("account not found")
+
+// ErrEmptyAccessToken defines error if BRI token response doesn't contain access token, e.g. because of invalid client id or secret.
+var ErrEmptyAccessToken = errors.New("empty access token")
+
+// DryRunError is returned by every call if Client.DryRun is enabled.
+// Request is the fully signed request (url, headers and body) which would have been sent to BRI.
+type DryRunError struct {
+	Request *http.Request
+}
+
+func (e *DryRunError) Error() string {
+	return "dry run: " + e.Request.Method + " " + e.Request.URL.String()
+}
+
+// ErrTooManyOTPResend defines error if BRI rejects OTP resend because of its resend rate limit.
+var ErrTooManyOTPResend = errors.New("too many OTP resend")
+
+// ErrCircuitOpen defines error if call is not sent because Client.CircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ErrInvalidResponseSignature defines error if Client.VerifyResponseSignature is enabled and BRI response signature doesn't match.
+var ErrInvalidResponseSignature = errors.New("invalid response signature")
+
+// ErrSandboxOnly defines error if sandbox only api, e.g. SimulatePaymentBRIVA, is called with production client.
+var ErrSandboxOnly = errors.New("api is only available in sandbox")
+
+// ErrInvalidAmount defines error if amount of charge or transfer is not a positive whole rupiah in IDR.
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// ErrInvalidPhoneNumber defines error if phone number is not a valid Indonesian mobile number, see NormalizePhoneID.
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// ErrBindingNotStarted defines error if BindingFlow is verified before it is started.
+var ErrBindingNotStarted = errors.New("card binding is not started")
+
+// ErrBindingNotVerified defines error if BindingFlow is charged before its card token is verified.
+var ErrBindingNotVerified = errors.New("card binding is not verified")
+
+// ErrQRISAlreadyPaid defines error if QRIS can't be cancelled because it is already paid.
+var ErrQRISAlreadyPaid = errors.New("qris is already paid")
+
+// ErrChargeAlreadySettled defines error if direct debit charge can't be cancelled because it is already settled.
+var ErrChargeAlreadySettled = errors.New("charge is already settled")
+
+// ErrRefundNotFound defines error if inquired direct debit refund doesn't exist.
+var ErrRefundNotFound = errors.New("refund not found")
+
+// ErrUnauthorized defines error if BRI responds 401, e.g. because access token is expired or client credential is invalid.
+// WithAccessToken refreshes the token and retries once on this error.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden defines error if BRI responds 403, e.g. because the credential isn't granted access to the api.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrInvalidURL defines error if BRI responds 404 without a decodable body, which means the requested url doesn't exist.
+var ErrInvalidURL = errors.New("invalid url")
+
+// ErrNotFound defines error if BRI responds 404 with a decodable body, e.g. payment not found.
+// The body is still decoded into the response, so BRI error detail is available.
+var ErrNotFound = errors.New("not found")
+
+// ErrMissingBaseURL defines error if Client.BaseUrl is not set.
+var ErrMissingBaseURL = errors.New("base url is not set")
+
+// ErrMissingDirectDebitBaseURL defines error if direct debit api is called but Client.DirectDebitBaseURL is not set.
+var ErrMissingDirectDebitBaseURL = errors.New("direct debit base url is not set")
+
+// ErrMissingClientID defines error if access token is requested but Client.ClientId is not set.
+var ErrMissingClientID = errors.New("client id is not set")
+
+// ErrMissingClientSecret defines error if request need to be signed but Client.ClientSecret is not set.
+// Without it BRI would reject the request with a confusing invalid signature error.
+var ErrMissingClientSecret = errors.New("client secret is not set")
+
+// ErrMissingAPIKey defines error if non production direct debit api is called but Client.APIKey is not set.
+var ErrMissingAPIKey = errors.New("api key is not set")
+
+// ErrResponseTooLarge defines error if BRI response body exceeds Client.MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response body is too large")
+
+// ErrMissingEnv defines error if NewClientFromEnv can't find required environment variables.
+// The error message lists the missing variables.
+var ErrMissingEnv = errors.New("missing required environment variable")
+
+// ErrConnection defines error if request can't be sent to BRI or no response is received, e.g. connection refused or timeout.
+var ErrConnection = errors.New("connection failed")
+
+// ErrEnvironmentMismatch defines error if Client.IsProduction doesn't match the environment of the called BRI host,
+// e.g. production host is called with IsProduction false. The request is not sent.
+var ErrEnvironmentMismatch = errors.New("client environment doesn't match BRI host")
+
+// ErrOTPExpired defines error if OTP is verified after it expires. Customer should request a new OTP.
+var ErrOTPExpired = errors.New("OTP is expired")
+
+// ErrInvalidOTP defines error if customer enters wrong OTP.
+var ErrInvalidOTP = errors.New("invalid OTP")
+
+// OTPError is returned by OTP verification if BRI rejects the OTP. Err is ErrOTPExpired or ErrInvalidOTP,
+// use errors.Is to check it. RemainingAttempts is 0 if BRI doesn't return it.
+type OTPError struct {
+	Err               error
+	RemainingAttempts int
+}
+
+func (e *OTPError) Error() string {
+	if e.RemainingAttempts > 0 {
+		return fmt.Sprintf("%v, %d attempts remaining", e.Err, e.RemainingAttempts)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns ErrOTPExpired or ErrInvalidOTP
+func (e *OTPError) Unwrap() error {
+	return e.Err
+}
+
+// ErrClockSkew defines error if local time differs from BRI server time more than Client.MaxClockSkew.
+// BRI rejects request signature if BRI-Timestamp is too far from its server time.
+var ErrClockSkew = errors.New("clock skew with BRI server is too large")
+
+// BRIError is returned if BRI responds with error payload, e.g. invalid signature or payment not found.
+// The same error is available in ErrorResponse of the response.
+type BRIError struct {
+	// StatusCode is status_code of the error payload
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *BRIError) Error() string {
+	return fmt.Sprintf("bri error %s: %s", e.Code, e.Message)
+}
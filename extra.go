@@ -0,0 +1,106 @@
+package bri
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// extraSetter is implemented by response struct which embeds ResponseMeta
+type extraSetter interface {
+	setExtra(extra map[string]json.RawMessage)
+}
+
+// unknownFields returns fields of json object data which have no matching struct field in type t, keyed by dotted path,
+// e.g. "body.new_field". Nested object is only inspected if its struct field is a struct too.
+func unknownFields(data []byte, t reflect.Type) map[string]json.RawMessage {
+	extra := make(map[string]json.RawMessage)
+	collectUnknownFields(data, t, "", extra)
+	if len(extra) == 0 {
+		return nil
+	}
+
+	return extra
+}
+
+func collectUnknownFields(data []byte, t reflect.Type, prefix string, extra map[string]json.RawMessage) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) != nil {
+		return
+	}
+
+	fields := jsonFields(t)
+	for key, value := range object {
+		field, ok := lookupField(fields, key)
+		if !ok {
+			extra[prefix+key] = value
+			continue
+		}
+
+		collectUnknownFields(value, field.Type, prefix+key+".", extra)
+	}
+}
+
+// jsonFields returns fields of struct type t by json name, including fields of embedded struct
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				// field of the outer struct takes precedence
+				for embeddedName, embeddedField := range jsonFields(embedded) {
+					if _, ok := fields[embeddedName]; !ok {
+						fields[embeddedName] = embeddedField
+					}
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+
+	return fields
+}
+
+// lookupField finds field by json key, case-insensitively like encoding/json does
+func lookupField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,87 @@
+package bri
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockClientSecret is client secret of the gateway calling mock BRI server
+const mockClientSecret = "mock-secret"
+
+// mockCase is a call to mock BRI server, it doesn't need credential_test.toml unlike BriSanguTestSuite.
+// serve handles request received by the server, call calls the gateway and asserts its result.
+// requests is the number of requests the server must receive, e.g. invalid request is rejected before it is sent.
+type mockCase struct {
+	name     string
+	serve    func(t *testing.T, w http.ResponseWriter, r *http.Request)
+	call     func(t *testing.T, gateway CoreGateway)
+	requests int32
+}
+
+// newMockGateway returns CoreGateway of which core and direct debit API are served by serverURL
+func newMockGateway(serverURL string) CoreGateway {
+	client := NewClient()
+	client.LogLevel = 0
+	client.BaseUrl = serverURL
+	client.DirectDebitBaseURL = serverURL
+	client.ClientSecret = mockClientSecret
+	client.APIKey = "api_key"
+
+	return CoreGateway{
+		Client: client,
+	}
+}
+
+// runMockCases runs every case against its own mock BRI server
+func runMockCases(t *testing.T, cases []mockCase) {
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				c.serve(t, w, r)
+			}))
+			defer server.Close()
+
+			c.call(t, newMockGateway(server.URL))
+			assert.Equal(t, c.requests, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+// assertCoreRequest asserts method, path and signature of core API request received by mock BRI server and returns its body.
+// path of GET request includes its query.
+func assertCoreRequest(t *testing.T, r *http.Request, method, path string) string {
+	body, _ := ioutil.ReadAll(r.Body)
+	timestamp := r.Header.Get("BRI-Timestamp")
+	assert.Equal(t, method, r.Method)
+	assert.Equal(t, path, r.URL.Path)
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+	signPath := path
+	if r.URL.RawQuery != "" {
+		signPath += "?" + r.URL.RawQuery
+	}
+	assert.Equal(t, generateSignature(signPath, method, "Bearer token", timestamp, string(body), mockClientSecret), r.Header.Get("BRI-Signature"))
+
+	return string(body)
+}
+
+// assertDirectDebitRequest asserts method, path and signature of direct debit request received by mock BRI server and returns its body
+func assertDirectDebitRequest(t *testing.T, r *http.Request, method, path string) string {
+	body, _ := ioutil.ReadAll(r.Body)
+	timestamp := r.Header.Get("BRI-Timestamp")
+	assert.Equal(t, method, r.Method)
+	assert.Equal(t, path, r.URL.Path)
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(t, ContentTypeJSON, r.Header.Get("Content-Type"))
+	assert.Equal(t, generateSignature(path, method, "Bearer token", timestamp, string(body), mockClientSecret), r.Header.Get("X-BRI-Signature"))
+
+	return string(body)
+}
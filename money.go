@@ -0,0 +1,136 @@
+package bri
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CurrencyIDR is the only currency of BRI direct debit and transfer
+const CurrencyIDR = "IDR"
+
+// Money defines amount in minor unit (1/100 of currency unit) and its currency.
+// It is marshalled into BRI amount string with two decimal places, e.g. NewMoney(1000000, "IDR") become "10000.00".
+// Currency is not part of the marshalled amount, request which needs currency has its own currency field.
+type Money struct {
+	Value    int64
+	Currency string
+}
+
+// NewMoney creates Money from amount in minor unit
+func NewMoney(value int64, currency string) Money {
+	return Money{
+		Value:    value,
+		Currency: currency,
+	}
+}
+
+// String returns BRI amount format, e.g. "10000.00"
+func (m Money) String() string {
+	sign := ""
+	value := m.Value
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+// MarshalJSON marshals money into BRI amount string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON unmarshals BRI amount, either string or number, into minor unit value. Currency is left untouched.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+
+	value, err := ParseBRIAmount(s)
+	if err != nil {
+		return err
+	}
+
+	m.Value = value
+	return nil
+}
+
+// ParseBRIAmount parses BRI amount string into minor unit, e.g. "10000.00" become 1000000.
+// Missing decimals ("10000"), thousands separator ("10,000.00") and negative amount of reversal ("-10000.00") are accepted.
+func ParseBRIAmount(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	// only the leading sign is allowed, both parts must be digits only
+	parts := strings.SplitN(s, ".", 2)
+	if parts[0] == "" {
+		return 0, errors.New("invalid amount: " + s)
+	}
+
+	// thousands separator must group exactly three digits, e.g. "1,250,000"
+	if groups := strings.Split(parts[0], ","); len(groups) > 1 {
+		for i, group := range groups {
+			if group == "" || len(group) > 3 || (i > 0 && len(group) != 3) {
+				return 0, errors.New("invalid amount, misplaced thousands separator: " + s)
+			}
+		}
+		parts[0] = strings.Join(groups, "")
+	}
+	for _, part := range parts {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, errors.New("invalid amount: " + s)
+			}
+		}
+	}
+
+	unit, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount: " + s)
+	}
+
+	var minor int64
+	if len(parts) == 2 {
+		decimal := parts[1]
+		if len(decimal) > 2 {
+			return 0, errors.New("invalid amount, more than two decimal places: " + s)
+		}
+
+		decimal += strings.Repeat("0", 2-len(decimal))
+		minor, err = strconv.ParseInt(decimal, 10, 64)
+		if err != nil {
+			return 0, errors.New("invalid amount: " + s)
+		}
+	}
+
+	if unit > (math.MaxInt64-minor)/100 {
+		return 0, errors.New("invalid amount, out of range: " + s)
+	}
+
+	value := unit*100 + minor
+	if negative {
+		value = -value
+	}
+
+	return value, nil
+}
+
+// validateIDRAmount returns ErrInvalidAmount if currency is not IDR or amount is not a positive whole rupiah.
+// currency is the currency field of the request, Money.Currency is checked too if it is set.
+func validateIDRAmount(amount Money, currency string) error {
+	if currency != CurrencyIDR || (amount.Currency != "" && amount.Currency != CurrencyIDR) {
+		return fmt.Errorf("%w: currency must be %s", ErrInvalidAmount, CurrencyIDR)
+	}
+
+	if amount.Value <= 0 || amount.Value%100 != 0 {
+		return fmt.Errorf("%w: %s is not a positive whole rupiah", ErrInvalidAmount, amount)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package bri
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestMoneyMarshalJSON() {
+	req := PaymentChargeOTPRequestData{
+		Amount:   NewMoney(1000000, "IDR"),
+		Currency: "IDR",
+	}
+
+	body, err := json.Marshal(req)
+	assert.Equal(bri.T(), nil, err)
+	assert.Contains(bri.T(), string(body), `"amount":"10000.00"`)
+
+	assert.Equal(bri.T(), "0.05", NewMoney(5, "IDR").String())
+	assert.Equal(bri.T(), "-150.50", NewMoney(-15050, "IDR").String())
+}
+
+func (bri *BriSanguTestSuite) TestMoneyUnmarshalJSON() {
+	var m struct {
+		String Money `json:"string"`
+		Number Money `json:"number"`
+		Short  Money `json:"short"`
+	}
+
+	err := json.Unmarshal([]byte(`{"string":"10000.00","number":89000.5,"short":"63000"}`), &m)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int64(1000000), m.String.Value)
+	assert.Equal(bri.T(), int64(8900050), m.Number.Value)
+	assert.Equal(bri.T(), int64(6300000), m.Short.Value)
+
+	err = json.Unmarshal([]byte(`{"string":"10000.001"}`), &m)
+	assert.NotNil(bri.T(), err)
+}
+
+func (bri *BriSanguTestSuite) TestParseBRIAmount() {
+	cases := map[string]int64{
+		"10000.00":             1000000,
+		"10000":                1000000,
+		"10000.5":              1000050,
+		"0.05":                 5,
+		"10,000.00":            1000000,
+		"1,250,000.75":         125000075,
+		"-10000.00":            -1000000,
+		"-1,500":               -150000,
+		" 89000.50 ":           8900050,
+		"92233720368547758.07": math.MaxInt64,
+	}
+	for s, expected := range cases {
+		value, err := ParseBRIAmount(s)
+		assert.Equal(bri.T(), nil, err, s)
+		assert.Equal(bri.T(), expected, value, s)
+	}
+
+	for _, s := range []string{"", "-", "abc", "10.000,00", "1.2.3", ".50", "--100", "10.-5", "+100", "10.+5", "- 100", "1,0,0", "1000,000", ",100", "100,", "10,000.0,0", "92233720368547758.08", "92233720368547759"} {
+		_, err := ParseBRIAmount(s)
+		assert.NotNil(bri.T(), err, s)
+	}
+}
+
+func (bri *BriSanguTestSuite) TestValidateIDRAmount() {
+	assert.Equal(bri.T(), nil, validateIDRAmount(NewMoney(1000000, CurrencyIDR), CurrencyIDR))
+	assert.Equal(bri.T(), nil, validateIDRAmount(Money{Value: 1000000}, CurrencyIDR))
+
+	invalid := []struct {
+		amount   Money
+		currency string
+	}{
+		{NewMoney(1000050, CurrencyIDR), CurrencyIDR},
+		{NewMoney(0, CurrencyIDR), CurrencyIDR},
+		{NewMoney(-1000000, CurrencyIDR), CurrencyIDR},
+		{NewMoney(1000000, "USD"), "USD"},
+		{NewMoney(1000000, "USD"), CurrencyIDR},
+		{NewMoney(1000000, CurrencyIDR), ""},
+	}
+	for _, c := range invalid {
+		err := validateIDRAmount(c.amount, c.currency)
+		assert.True(bri.T(), errors.Is(err, ErrInvalidAmount), "%v %s", c.amount, c.currency)
+	}
+
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	_, err := coreGateway.CreatePaymentChargeOTP("token", "key", NewPaymentChargeOTPRequest("card_.eyJ", NewMoney(1000050, CurrencyIDR), "order"))
+	assert.True(bri.T(), errors.Is(err, ErrInvalidAmount))
+
+	_, err = coreGateway.TransferIntrabank("token", IntrabankTransferRequest{Amount: NewMoney(50, CurrencyIDR)})
+	assert.True(bri.T(), errors.Is(err, ErrInvalidAmount))
+}
+
+func (bri *BriSanguTestSuite) TestResponseFee() {
+	var transfer TransferStatusResponse
+	err := json.Unmarshal([]byte(`{"data":{"noReferral":"1","amount":"100000.00","status":"SUCCESS","fee":"6500.00"}}`), &transfer)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int64(650000), transfer.Data.Fee.Value)
+	assert.Equal(bri.T(), int64(10000000), transfer.Data.Amount.Value)
+
+	var charge ChargeDetailResponse
+	err = json.Unmarshal([]byte(`{"body":{"amount":"10000.00","fee":"150.00","net_amount":"9850.00"}}`), &charge)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int64(15000), charge.Body.Fee.Value)
+	assert.Equal(bri.T(), int64(985000), charge.Body.NetAmount.Value)
+	assert.Equal(bri.T(), int64(1000000), charge.Body.Amount.Value)
+
+	var refund RefundStatusResponse
+	err = json.Unmarshal([]byte(`{"body":{"amount":"5,000.00","fee":"100"}}`), &refund)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int64(500000), refund.Body.Amount.Value)
+	assert.Equal(bri.T(), int64(10000), refund.Body.Fee.Value)
+
+	// BRI doesn't return fee
+	var payment PaymentChargeResponse
+	err = json.Unmarshal([]byte(`{"body":{"amount":"10000.00"}}`), &payment)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), int64(0), payment.Body.Fee.Value)
+	assert.Equal(bri.T(), int64(1000000), payment.Body.Amount.Value)
+}
@@ -0,0 +1,40 @@
+package bri
+
+import (
+	"fmt"
+	"strings"
+)
+
+// minPhoneIDLength and maxPhoneIDLength are length bounds of Indonesian mobile number in local format, e.g. "081234567890"
+const (
+	minPhoneIDLength = 10
+	maxPhoneIDLength = 13
+)
+
+// NormalizePhoneID normalizes Indonesian mobile number into local format expected by BRI, e.g. "+62 812-3456-7890",
+// "6281234567890" and "81234567890" become "081234567890". Spaces, dashes, dots and parentheses are removed.
+// ErrInvalidPhoneNumber is returned if it is not a valid Indonesian mobile number.
+func NormalizePhoneID(s string) (string, error) {
+	phone := strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(s)
+
+	switch {
+	case strings.HasPrefix(phone, "+62"):
+		phone = "0" + phone[3:]
+	case strings.HasPrefix(phone, "62"):
+		phone = "0" + phone[2:]
+	case strings.HasPrefix(phone, "8"):
+		phone = "0" + phone
+	}
+
+	if !strings.HasPrefix(phone, "08") || len(phone) < minPhoneIDLength || len(phone) > maxPhoneIDLength {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPhoneNumber, s)
+	}
+
+	for _, c := range phone {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("%w: %s", ErrInvalidPhoneNumber, s)
+		}
+	}
+
+	return phone, nil
+}
@@ -0,0 +1,37 @@
+package bri
+
+import (
+	"errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestNormalizePhoneID() {
+	cases := map[string]string{
+		"081234567890":      "081234567890",
+		"+6281234567890":    "081234567890",
+		"6281234567890":     "081234567890",
+		"81234567890":       "081234567890",
+		"+62 812-3456-7890": "081234567890",
+		"(0812) 3456.789":   "08123456789",
+	}
+	for s, expected := range cases {
+		phone, err := NormalizePhoneID(s)
+		assert.Equal(bri.T(), nil, err, s)
+		assert.Equal(bri.T(), expected, phone, s)
+	}
+
+	for _, s := range []string{"", "0812345", "02112345678", "08123456789012", "0812345678a", "+1 415 555 0100"} {
+		_, err := NormalizePhoneID(s)
+		assert.True(bri.T(), errors.Is(err, ErrInvalidPhoneNumber), s)
+	}
+}
+
+func (bri *BriSanguTestSuite) TestCreateCardTokenOTPInvalidPhone() {
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	_, err := coreGateway.CreateCardTokenOTP("token", NewCardTokenOTPRequest("5221123456789012", "12345", "user@example.com"))
+	assert.True(bri.T(), errors.Is(err, ErrInvalidPhoneNumber))
+}
@@ -0,0 +1,80 @@
+package bri
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PingReason classifies why Ping failed
+type PingReason string
+
+const (
+	// PingNetwork means BRI can't be reached, e.g. dns failure, connection refused or timeout
+	PingNetwork PingReason = "network"
+	// PingAuth means BRI rejects the client id or client secret
+	PingAuth PingReason = "auth"
+	// PingSignature means signed request will be rejected, e.g. client secret is not set or local clock is skewed
+	PingSignature PingReason = "signature"
+	// PingUnexpected means BRI responds with something else, e.g. maintenance page
+	PingUnexpected PingReason = "unexpected"
+)
+
+// PingError is returned by Ping, Err is the underlying error
+type PingError struct {
+	Reason PingReason
+	Err    error
+}
+
+func (e *PingError) Error() string {
+	return fmt.Sprintf("bri ping failed (%s): %v", e.Reason, e.Err)
+}
+
+func (e *PingError) Unwrap() error {
+	return e.Err
+}
+
+// Ping checks that BRI is reachable and the credential is valid by requesting an access token, no transaction is created.
+// It returns *PingError describing the failure, e.g. to fail readiness probe or skip a batch job early.
+// Clock skew of BRI token response is checked against Client.MaxClockSkew (default 1 minute), since BRI rejects signature of a skewed timestamp.
+// Stored token of AccessToken is not replaced.
+func (gateway *CoreGateway) Ping(ctx context.Context) error {
+	if gateway.Client.ClientSecret == "" {
+		return &PingError{Reason: PingSignature, Err: ErrMissingClientSecret}
+	}
+
+	res, err := gateway.getToken(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrConnection), ctx.Err() != nil:
+			return &PingError{Reason: PingNetwork, Err: err}
+		case errors.Is(err, ErrMissingClientID), errors.Is(err, ErrUnauthorized), errors.Is(err, ErrForbidden):
+			return &PingError{Reason: PingAuth, Err: err}
+		default:
+			return &PingError{Reason: PingUnexpected, Err: err}
+		}
+	}
+
+	raw := res.RawResponse()
+	if res.AccessToken == "" {
+		if raw != nil {
+			return &PingError{Reason: PingAuth, Err: fmt.Errorf("%w: http status %d", ErrEmptyAccessToken, raw.StatusCode)}
+		}
+		return &PingError{Reason: PingAuth, Err: ErrEmptyAccessToken}
+	}
+
+	if raw == nil {
+		return nil
+	}
+
+	maxSkew := gateway.Client.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = defMaxClockSkew
+	}
+
+	if skew, ok := gateway.Client.clockSkew(raw.Header); ok && absDuration(skew) > maxSkew {
+		return &PingError{Reason: PingSignature, Err: fmt.Errorf("%w: %v", ErrClockSkew, skew)}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package bri
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestPing() {
+	var status int
+	var date string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date)
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"access_token":"token","expires_in":"3599"}`))
+			return
+		}
+		w.Write([]byte(`{"ErrorCode":"invalid_client","Error":"ClientId is Invalid"}`))
+	}))
+
+	bri.client.BaseUrl = server.URL
+	gateway := CoreGateway{Client: bri.client}
+	ctx := context.Background()
+
+	status, date = http.StatusOK, time.Now().UTC().Format(http.TimeFormat)
+	assert.Equal(bri.T(), nil, gateway.Ping(ctx))
+
+	var pingErr *PingError
+	status = http.StatusUnauthorized
+	err := gateway.Ping(ctx)
+	assert.True(bri.T(), errors.As(err, &pingErr))
+	assert.Equal(bri.T(), PingAuth, pingErr.Reason)
+	assert.True(bri.T(), errors.Is(err, ErrUnauthorized))
+
+	status, date = http.StatusOK, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	err = gateway.Ping(ctx)
+	assert.True(bri.T(), errors.As(err, &pingErr))
+	assert.Equal(bri.T(), PingSignature, pingErr.Reason)
+	assert.True(bri.T(), errors.Is(err, ErrClockSkew))
+
+	server.Close()
+	err = gateway.Ping(ctx)
+	assert.True(bri.T(), errors.As(err, &pingErr))
+	assert.Equal(bri.T(), PingNetwork, pingErr.Reason)
+	assert.True(bri.T(), errors.Is(err, ErrConnection))
+
+	gateway.Client.ClientSecret = ""
+	err = gateway.Ping(ctx)
+	assert.True(bri.T(), errors.As(err, &pingErr))
+	assert.Equal(bri.T(), PingSignature, pingErr.Reason)
+}
@@ -0,0 +1,90 @@
+package bri
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	urlGenerateQRIS = "/v1/qris/generate" // POST
+	urlQRISStatus   = "/v1/qris/status"   // POST
+	urlCancelQRIS   = "/v1/qris/cancel"   // POST
+)
+
+// QRIS payment status value
+const (
+	QRISStatusPaid      = "PAID"
+	QRISStatusUnpaid    = "UNPAID"
+	QRISStatusExpired   = "EXPIRED"
+	QRISStatusCancelled = "CANCELLED"
+)
+
+// qrisRespCodeExpired is BRI response code when the inquired QR is already expired
+const qrisRespCodeExpired = "0107"
+
+// qrisRespCodeAlreadyPaid is BRI response code when the QR to cancel is already paid
+const qrisRespCodeAlreadyPaid = "0108"
+
+// GenerateQRIS generates dynamic QRIS content for merchant payment.
+// Response contains QR content string and QR image url which can be shown to the customer.
+func (g *CoreGateway) GenerateQRIS(token string, req QRISRequest) (res QRISResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlGenerateQRIS, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.Call(method, urlGenerateQRIS, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// QRISStatus inquires QRIS payment status by transaction reference.
+// Expired QR is returned as QRISStatusExpired status instead of an error, so merchant can ask the customer to regenerate it.
+func (g *CoreGateway) QRISStatus(token string, req QRISStatusRequest) (res QRISStatusResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlQRISStatus, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.Call(method, urlQRISStatus, headers, strings.NewReader(string(body)), &res, nil)
+	if err != nil {
+		return
+	}
+
+	if res.ResponseCode == qrisRespCodeExpired {
+		res.Data.ReferenceNo = req.ReferenceNo
+		res.Data.Status = QRISStatusExpired
+	}
+
+	return
+}
+
+// CancelQRIS voids unpaid dynamic QR by transaction reference, e.g. when the order is cancelled.
+// ErrQRISAlreadyPaid is returned if the customer has paid the QR, the payment should be refunded instead.
+func (g *CoreGateway) CancelQRIS(token string, req CancelQRISRequest) (res CancelQRISResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlCancelQRIS, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.Call(method, urlCancelQRIS, headers, strings.NewReader(string(body)), &res, nil)
+	if err != nil {
+		return
+	}
+
+	if res.ResponseCode == qrisRespCodeAlreadyPaid {
+		err = ErrQRISAlreadyPaid
+		return
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+package bri
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQRIS(t *testing.T) {
+	runMockCases(t, []mockCase{
+		{
+			name: "GenerateQRIS",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				body := assertCoreRequest(t, r, http.MethodPost, urlGenerateQRIS)
+				assert.Equal(t, ContentTypeJSON, r.Header.Get("Content-Type"))
+				assert.Equal(t, `{"merchantId":"merchant","amount":"10000.00","referenceNo":"ref-1"}`, body)
+
+				w.Write([]byte(`{"responseCode":"0000","responseDescription":"Success","data":{"merchantId":"merchant","referenceNo":"ref-1","amount":"10000.00","qrContent":"00020101021226","qrImageUrl":"https://example.com/qr.png","expiredDate":"2021-11-02 13:30:00"}}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.GenerateQRIS("token", QRISRequest{MerchantID: "merchant", Amount: "10000.00", ReferenceNo: "ref-1"})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "00020101021226", resp.Data.QRContent)
+				assert.Equal(t, "https://example.com/qr.png", resp.Data.QRImageURL)
+			},
+			requests: 1,
+		},
+		{
+			name: "QRISStatus",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch assertCoreRequest(t, r, http.MethodPost, urlQRISStatus) {
+				case `{"merchantId":"merchant","referenceNo":"ref-1"}`:
+					w.Write([]byte(`{"responseCode":"0000","data":{"referenceNo":"ref-1","status":"PAID","paidAmount":"10000.00","paymentDate":"2021-11-02 13:10:00"}}`))
+				default:
+					w.Write([]byte(`{"responseCode":"0107","responseDescription":"QR expired"}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.QRISStatus("token", QRISStatusRequest{MerchantID: "merchant", ReferenceNo: "ref-1"})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, QRISStatusPaid, resp.Data.Status)
+				assert.Equal(t, int64(1000000), resp.Data.PaidAmount.Value)
+
+				// expired QR is a status, not an error
+				resp, err = gateway.QRISStatus("token", QRISStatusRequest{MerchantID: "merchant", ReferenceNo: "ref-2"})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, QRISStatusExpired, resp.Data.Status)
+				assert.Equal(t, "ref-2", resp.Data.ReferenceNo)
+			},
+			requests: 2,
+		},
+		{
+			name: "CancelQRIS",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch assertCoreRequest(t, r, http.MethodPost, urlCancelQRIS) {
+				case `{"merchantId":"merchant","referenceNo":"ref-1"}`:
+					w.Write([]byte(`{"responseCode":"0000","data":{"referenceNo":"ref-1","status":"CANCELLED"}}`))
+				default:
+					w.Write([]byte(`{"responseCode":"` + qrisRespCodeAlreadyPaid + `"}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.CancelQRIS("token", CancelQRISRequest{MerchantID: "merchant", ReferenceNo: "ref-1"})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, QRISStatusCancelled, resp.Data.Status)
+
+				_, err = gateway.CancelQRIS("token", CancelQRISRequest{MerchantID: "merchant", ReferenceNo: "ref-paid"})
+				assert.Equal(t, ErrQRISAlreadyPaid, err)
+			},
+			requests: 2,
+		},
+	})
+}
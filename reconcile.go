@@ -0,0 +1,45 @@
+package bri
+
+import "sort"
+
+// ReconcileResult is result of Reconcile
+type ReconcileResult struct {
+	// Matched are report payments which are expected with the same amount
+	Matched []VaReportData
+	// AmountMismatch are report payments which are expected but with different amount
+	AmountMismatch []VaReportData
+	// Missing are customer codes which are expected but not paid in the report, sorted
+	Missing []string
+	// Extra are report payments which are not expected, including repeated payment of the same customer code
+	Extra []VaReportData
+}
+
+// Reconcile diffs BRIVA report against expected payments keyed by customer code, e.g. for daily settlement.
+// Every customer code is matched with at most one report payment.
+func Reconcile(report VaReportResponse, expected map[string]Money) (res ReconcileResult) {
+	paid := map[string]bool{}
+	for _, data := range report.Data {
+		amount, ok := expected[data.CustCode]
+		if !ok || paid[data.CustCode] {
+			res.Extra = append(res.Extra, data)
+			continue
+		}
+
+		paid[data.CustCode] = true
+		if data.Amount.Value != amount.Value {
+			res.AmountMismatch = append(res.AmountMismatch, data)
+			continue
+		}
+
+		res.Matched = append(res.Matched, data)
+	}
+
+	for custCode := range expected {
+		if !paid[custCode] {
+			res.Missing = append(res.Missing, custCode)
+		}
+	}
+	sort.Strings(res.Missing)
+
+	return
+}
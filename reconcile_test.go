@@ -0,0 +1,26 @@
+package bri
+
+import "github.com/stretchr/testify/assert"
+
+func (bri *BriSanguTestSuite) TestReconcile() {
+	report := VaReportResponse{
+		Data: []VaReportData{
+			{BrivaNo: "77777", CustCode: "001", Amount: NewMoney(1000000, "")},
+			{BrivaNo: "77777", CustCode: "002", Amount: NewMoney(1500000, "")},
+			{BrivaNo: "77777", CustCode: "001", Amount: NewMoney(1000000, "")},
+			{BrivaNo: "77777", CustCode: "009", Amount: NewMoney(500000, "")},
+		},
+	}
+	expected := map[string]Money{
+		"001": NewMoney(1000000, "IDR"),
+		"002": NewMoney(2000000, "IDR"),
+		"003": NewMoney(500000, "IDR"),
+		"004": NewMoney(500000, "IDR"),
+	}
+
+	res := Reconcile(report, expected)
+	assert.Equal(bri.T(), []VaReportData{report.Data[0]}, res.Matched)
+	assert.Equal(bri.T(), []VaReportData{report.Data[1]}, res.AmountMismatch)
+	assert.Equal(bri.T(), []string{"003", "004"}, res.Missing)
+	assert.Equal(bri.T(), []VaReportData{report.Data[2], report.Data[3]}, res.Extra)
+}
@@ -1,5 +1,11 @@
 package bri
 
+// BRIVA account type
+const (
+	BRIVAAccountTypeDynamic = "DYNAMIC"
+	BRIVAAccountTypeStatic  = "STATIC"
+)
+
 type CreateVaRequest struct {
 	InstitutionCode string `json:"institutionCode"`
 	BrivaNo         string `json:"brivaNo"`
@@ -8,6 +14,24 @@ type CreateVaRequest struct {
 	Amount          string `json:"amount"`
 	Description     string `json:"keterangan"`
 	ExpiredDate     string `json:"expiredDate"`
+	// AccountType is BRIVAAccountTypeDynamic (single payment, default if empty) or BRIVAAccountTypeStatic (accepts multiple payments)
+	AccountType string `json:"accountType,omitempty"`
+}
+
+// UpdateBRIVAExpiryRequest defines payload for BRIVA - update expiry. ExpiredDate format is "2006-01-02 15:04:05".
+type UpdateBRIVAExpiryRequest struct {
+	InstitutionCode string `json:"institutionCode"`
+	BrivaNo         string `json:"brivaNo"`
+	CustCode        string `json:"custCode"`
+	ExpiredDate     string `json:"expiredDate"`
+}
+
+// SimulatePaymentRequest defines payload for BRIVA - simulate payment (sandbox only)
+type SimulatePaymentRequest struct {
+	InstitutionCode string `json:"institutionCode"`
+	BrivaNo         string `json:"brivaNo"`
+	CustCode        string `json:"custCode"`
+	Amount          string `json:"amount"`
 }
 
 type GetReportVaRequest struct {
@@ -15,6 +39,10 @@ type GetReportVaRequest struct {
 	BrivaNo         string
 	StartDate       string
 	EndDate         string
+
+	// Page and Limit are sent as query parameter if any of them is set
+	Page  int
+	Limit int
 }
 
 // Location defines location data
@@ -36,6 +64,18 @@ type CardTokenOTPRequestData struct {
 	OtpBriStatus string `json:"otp_bri_status"`
 }
 
+// NewCardTokenOTPRequest creates create card token OTP request with BRI OTP enabled
+func NewCardTokenOTPRequest(cardPan, phoneNumber, email string) CardTokenOTPRequest {
+	return CardTokenOTPRequest{
+		Body: CardTokenOTPRequestData{
+			CardPan:      cardPan,
+			PhoneNumber:  phoneNumber,
+			Email:        email,
+			OtpBriStatus: "YES",
+		},
+	}
+}
+
 // CardTokenOTPVerifyRequest defines payload for direct debit - create card token OTP verify
 type CardTokenOTPVerifyRequest struct {
 	Body CardTokenOTPVerifyRequestData `json:"body"`
@@ -47,6 +87,16 @@ type CardTokenOTPVerifyRequestData struct {
 	Passcode          string `json:"passcode"`
 }
 
+// NewCardTokenOTPVerifyRequest creates create card token OTP verify request
+func NewCardTokenOTPVerifyRequest(registrationToken, passcode string) CardTokenOTPVerifyRequest {
+	return CardTokenOTPVerifyRequest{
+		Body: CardTokenOTPVerifyRequestData{
+			RegistrationToken: registrationToken,
+			Passcode:          passcode,
+		},
+	}
+}
+
 // PaymentChargeOTPRequest defines payload for direct debit - create payment charge OTP
 type PaymentChargeOTPRequest struct {
 	Body PaymentChargeOTPRequestData `json:"body"`
@@ -55,11 +105,36 @@ type PaymentChargeOTPRequest struct {
 // PaymentChargeOTPRequestData defines data payload for direct debit - create payment charge OTP
 type PaymentChargeOTPRequestData struct {
 	CardToken    string                 `json:"card_token"`
-	Amount       string                 `json:"amount"`
+	Amount       Money                  `json:"amount"`
 	Currency     string                 `json:"currency"`
 	Remarks      string                 `json:"remarks"`
 	OtpBriStatus string                 `json:"otp_bri_status"`
 	Metadata     map[string]interface{} `json:"metadata"`
+	// Installment is optional, charge is full amount charge if it is nil
+	Installment *Installment `json:"installment,omitempty"`
+	// ThreeDSecure requests 3-D Secure authentication for card program which mandates it,
+	// the customer is redirected to ThreeDSRedirectURL of the response and back to ReturnURL afterward.
+	ThreeDSecure bool   `json:"three_ds,omitempty"`
+	ReturnURL    string `json:"return_url,omitempty"`
+}
+
+// NewPaymentChargeOTPRequest creates create payment charge request with BRI OTP enabled. Currency is taken from amount.
+func NewPaymentChargeOTPRequest(cardToken string, amount Money, remarks string) PaymentChargeOTPRequest {
+	return PaymentChargeOTPRequest{
+		Body: PaymentChargeOTPRequestData{
+			CardToken:    cardToken,
+			Amount:       amount,
+			Currency:     amount.Currency,
+			Remarks:      remarks,
+			OtpBriStatus: "YES",
+		},
+	}
+}
+
+// Installment defines card installment (cicilan) plan of direct debit charge
+type Installment struct {
+	Tenor    int    `json:"tenor"`
+	PlanCode string `json:"plan_code"`
 }
 
 // PaymentChargeOTPVerifyRequest defines payload for direct debit - create payment charge OTP verify
@@ -74,6 +149,17 @@ type PaymentChargeOTPVerifyRequestData struct {
 	Passcode    string `json:"passcode"`
 }
 
+// NewPaymentChargeOTPVerifyRequest creates create payment charge OTP verify request
+func NewPaymentChargeOTPVerifyRequest(cardToken, chargeToken, passcode string) PaymentChargeOTPVerifyRequest {
+	return PaymentChargeOTPVerifyRequest{
+		Body: PaymentChargeOTPVerifyRequestData{
+			CardToken:   cardToken,
+			ChargeToken: chargeToken,
+			Passcode:    passcode,
+		},
+	}
+}
+
 // DeleteCardTokenRequest defines payload for direct debit - delete card token
 type DeleteCardTokenRequest struct {
 	Body DeleteCardTokenRequestData `json:"body"`
@@ -84,6 +170,15 @@ type DeleteCardTokenRequestData struct {
 	CardToken string `json:"card_token"`
 }
 
+// NewDeleteCardTokenRequest creates delete card token request
+func NewDeleteCardTokenRequest(cardToken string) DeleteCardTokenRequest {
+	return DeleteCardTokenRequest{
+		Body: DeleteCardTokenRequestData{
+			CardToken: cardToken,
+		},
+	}
+}
+
 // ChargeDetailRequest defines payload for direct debit - charge detail
 type ChargeDetailRequest struct {
 	Body ChargeDetailRequestData `json:"body"`
@@ -96,6 +191,15 @@ type ChargeDetailRequestData struct {
 	Metadata  map[string]interface{} `json:"metadata"`
 }
 
+// NewChargeDetailRequest creates charge detail request
+func NewChargeDetailRequest(paymentID string) ChargeDetailRequest {
+	return ChargeDetailRequest{
+		Body: ChargeDetailRequestData{
+			PaymentID: paymentID,
+		},
+	}
+}
+
 // RefundRequest defines payload for direct debit - refund
 type RefundRequest struct {
 	Body RefundRequestData `json:"body"`
@@ -104,15 +208,304 @@ type RefundRequest struct {
 // RefundRequestData defines data payload for direct debit - refund
 type RefundRequestData struct {
 	CardToken string                 `json:"card_token"`
-	Amount    string                 `json:"amount"`
+	Amount    Money                  `json:"amount"`
 	PaymentID string                 `json:"payment_id"`
 	Currency  string                 `json:"currency"`
 	Reason    string                 `json:"reason"`
 	Metadata  map[string]interface{} `json:"metadata"`
 }
 
+// NewRefundRequest creates refund request. Currency is taken from amount.
+func NewRefundRequest(cardToken, paymentID string, amount Money, reason string) RefundRequest {
+	return RefundRequest{
+		Body: RefundRequestData{
+			CardToken: cardToken,
+			PaymentID: paymentID,
+			Amount:    amount,
+			Currency:  amount.Currency,
+			Reason:    reason,
+		},
+	}
+}
+
 type GetMutationRequest struct {
 	AccountNumber string `json:"accountNumber"`
 	StartDate     string `json:"startDate"`
 	EndDate       string `json:"endDate"`
 }
+
+// AccountStatementRequest defines payload for account statement (mutation) inquiry
+type AccountStatementRequest struct {
+	AccountNumber string `json:"accountNumber"`
+	StartDate     string `json:"startDate"`
+	EndDate       string `json:"endDate"`
+	// Page and Limit are only sent if Limit is set
+	Page  int `json:"page,omitempty"`
+	Limit int `json:"limit,omitempty"`
+}
+
+// QRISRequest defines payload for QRIS - generate dynamic QR
+type QRISRequest struct {
+	MerchantID  string `json:"merchantId"`
+	Amount      string `json:"amount"`
+	ReferenceNo string `json:"referenceNo"`
+}
+
+// QRISStatusRequest defines payload for QRIS - payment status inquiry
+type QRISStatusRequest struct {
+	MerchantID  string `json:"merchantId"`
+	ReferenceNo string `json:"referenceNo"`
+}
+
+// CancelQRISRequest defines payload for QRIS - cancel unpaid QR
+type CancelQRISRequest struct {
+	MerchantID  string `json:"merchantId"`
+	ReferenceNo string `json:"referenceNo"`
+}
+
+// SnapTokenRequest defines payload for SNAP BI - access token B2B
+type SnapTokenRequest struct {
+	GrantType string `json:"grantType"`
+}
+
+// SnapAmount defines SNAP BI amount object, e.g. {"value":"10000.00","currency":"IDR"}
+type SnapAmount struct {
+	Value    Money  `json:"value"`
+	Currency string `json:"currency"`
+}
+
+// NewSnapAmount creates SnapAmount from money, currency is taken from money
+func NewSnapAmount(money Money) SnapAmount {
+	return SnapAmount{
+		Value:    money,
+		Currency: money.Currency,
+	}
+}
+
+// BalanceInquiryRequest defines payload for account balance inquiry
+type BalanceInquiryRequest struct {
+	AccountNumber string
+}
+
+// IntrabankTransferRequest defines payload for fund transfer - intrabank (BRI to BRI)
+type IntrabankTransferRequest struct {
+	// ExternalID is sent as BRI-External-Id header and used by BRI as idempotency key
+	ExternalID string `json:"-"`
+
+	NoReferral          string `json:"NoReferral"`
+	SourceAccount       string `json:"sourceAccount"`
+	BeneficiaryAccount  string `json:"beneficiaryAccount"`
+	Amount              Money  `json:"Amount"`
+	FeeType             string `json:"FeeType"`
+	TransactionDateTime string `json:"transactionDateTime"`
+	Remark              string `json:"remark"`
+}
+
+// InterbankTransferRequest defines payload for fund transfer - interbank (BRI to other bank)
+type InterbankTransferRequest struct {
+	// ExternalID is sent as BRI-External-Id header and used by BRI as idempotency key
+	ExternalID string `json:"-"`
+
+	NoReferral             string `json:"noReferral"`
+	BankCode               string `json:"bankCode"`
+	SourceAccount          string `json:"sourceAccount"`
+	BeneficiaryAccount     string `json:"beneficiaryAccount"`
+	BeneficiaryAccountName string `json:"beneficiaryAccountName"`
+	Amount                 Money  `json:"Amount"`
+	TransactionDateTime    string `json:"transactionDateTime"`
+	Remark                 string `json:"remark"`
+}
+
+// TransferStatusRequest defines payload for fund transfer - status inquiry
+type TransferStatusRequest struct {
+	NoReferral      string `json:"noReferral"`
+	TransactionDate string `json:"transactionDate"`
+}
+
+// BulkTransferRequest defines payload for fund transfer - bulk transfer
+type BulkTransferRequest struct {
+	// ExternalID is sent as BRI-External-Id header and used by BRI as idempotency key
+	ExternalID string `json:"-"`
+
+	SourceAccount       string             `json:"sourceAccount"`
+	TransactionDateTime string             `json:"transactionDateTime"`
+	Items               []BulkTransferItem `json:"items"`
+}
+
+// BulkTransferItem defines a beneficiary of bulk transfer, BankCode is "002" for BRI account
+type BulkTransferItem struct {
+	NoReferral             string `json:"noReferral"`
+	BankCode               string `json:"bankCode"`
+	BeneficiaryAccount     string `json:"beneficiaryAccount"`
+	BeneficiaryAccountName string `json:"beneficiaryAccountName"`
+	Amount                 Money  `json:"amount"`
+	Remark                 string `json:"remark"`
+}
+
+// BulkTransferStatusRequest defines payload for fund transfer - bulk transfer status inquiry
+type BulkTransferStatusRequest struct {
+	BatchReference string `json:"batchReference"`
+}
+
+// AccountInquiryRequest defines payload for beneficiary account inquiry
+type AccountInquiryRequest struct {
+	BankCode      string
+	AccountNumber string
+}
+
+// CardTokenStatusRequest defines payload for direct debit - card token status inquiry
+type CardTokenStatusRequest struct {
+	Body CardTokenStatusRequestData `json:"body"`
+}
+
+// CardTokenStatusRequestData defines data payload for direct debit - card token status inquiry
+type CardTokenStatusRequestData struct {
+	CardToken string `json:"card_token"`
+}
+
+// NewCardTokenStatusRequest creates card token status inquiry request
+func NewCardTokenStatusRequest(cardToken string) CardTokenStatusRequest {
+	return CardTokenStatusRequest{
+		Body: CardTokenStatusRequestData{
+			CardToken: cardToken,
+		},
+	}
+}
+
+// ResendOTPRequest defines payload for direct debit - resend card token OTP
+type ResendOTPRequest struct {
+	Body ResendOTPRequestData `json:"body"`
+}
+
+// ResendOTPRequestData defines data payload for direct debit - resend card token OTP
+type ResendOTPRequestData struct {
+	RegistrationToken string `json:"registration_token"`
+}
+
+// NewResendOTPRequest creates resend card token OTP request
+func NewResendOTPRequest(registrationToken string) ResendOTPRequest {
+	return ResendOTPRequest{
+		Body: ResendOTPRequestData{
+			RegistrationToken: registrationToken,
+		},
+	}
+}
+
+// CancelChargeRequest defines payload for direct debit - cancel charge
+type CancelChargeRequest struct {
+	Body CancelChargeRequestData `json:"body"`
+}
+
+// CancelChargeRequestData defines data payload for direct debit - cancel charge
+type CancelChargeRequestData struct {
+	PaymentID string `json:"payment_id"`
+	Reason    string `json:"reason"`
+}
+
+// NewCancelChargeRequest creates cancel charge request
+func NewCancelChargeRequest(paymentID, reason string) CancelChargeRequest {
+	return CancelChargeRequest{
+		Body: CancelChargeRequestData{
+			PaymentID: paymentID,
+			Reason:    reason,
+		},
+	}
+}
+
+// ListCardTokensRequest defines payload for direct debit - list card tokens
+type ListCardTokensRequest struct {
+	Body ListCardTokensRequestData `json:"body"`
+}
+
+// ListCardTokensRequestData defines data payload for direct debit - list card tokens
+type ListCardTokensRequestData struct {
+	PhoneNumber string `json:"phone_number"`
+	Email       string `json:"email"`
+}
+
+// NewListCardTokensRequest creates list card tokens request
+func NewListCardTokensRequest(phoneNumber, email string) ListCardTokensRequest {
+	return ListCardTokensRequest{
+		Body: ListCardTokensRequestData{
+			PhoneNumber: phoneNumber,
+			Email:       email,
+		},
+	}
+}
+
+// RefundStatusRequest defines payload for direct debit - refund status inquiry
+type RefundStatusRequest struct {
+	Body RefundStatusRequestData `json:"body"`
+}
+
+// RefundStatusRequestData defines data payload for direct debit - refund status inquiry
+type RefundStatusRequestData struct {
+	RefundID string `json:"refund_id"`
+}
+
+// NewRefundStatusRequest creates refund status inquiry request
+func NewRefundStatusRequest(refundID string) RefundStatusRequest {
+	return RefundStatusRequest{
+		Body: RefundStatusRequestData{
+			RefundID: refundID,
+		},
+	}
+}
+
+// Recurring schedule frequency
+const (
+	RecurringFrequencyDaily   = "DAILY"
+	RecurringFrequencyWeekly  = "WEEKLY"
+	RecurringFrequencyMonthly = "MONTHLY"
+)
+
+// RecurringRegisterRequest defines payload for direct debit - register recurring
+type RecurringRegisterRequest struct {
+	Body RecurringRegisterRequestData `json:"body"`
+}
+
+// RecurringRegisterRequestData defines data payload for direct debit - register recurring.
+// StartDate format is "2006-01-02", Frequency is one of RecurringFrequencyDaily, RecurringFrequencyWeekly or RecurringFrequencyMonthly.
+type RecurringRegisterRequestData struct {
+	CardToken string                 `json:"card_token"`
+	Amount    Money                  `json:"amount"`
+	Currency  string                 `json:"currency"`
+	Frequency string                 `json:"frequency"`
+	StartDate string                 `json:"start_date"`
+	Remarks   string                 `json:"remarks"`
+	Metadata  map[string]interface{} `json:"metadata"`
+}
+
+// NewRecurringRegisterRequest creates register recurring request. Currency is taken from amount.
+func NewRecurringRegisterRequest(cardToken string, amount Money, frequency, startDate string) RecurringRegisterRequest {
+	return RecurringRegisterRequest{
+		Body: RecurringRegisterRequestData{
+			CardToken: cardToken,
+			Amount:    amount,
+			Currency:  amount.Currency,
+			Frequency: frequency,
+			StartDate: startDate,
+		},
+	}
+}
+
+// RecurringUnregisterRequest defines payload for direct debit - unregister recurring
+type RecurringUnregisterRequest struct {
+	Body RecurringUnregisterRequestData `json:"body"`
+}
+
+// RecurringUnregisterRequestData defines data payload for direct debit - unregister recurring
+type RecurringUnregisterRequestData struct {
+	CardToken   string `json:"card_token"`
+	RecurringID string `json:"recurring_id"`
+}
+
+// NewRecurringUnregisterRequest creates unregister recurring request
+func NewRecurringUnregisterRequest(cardToken, recurringID string) RecurringUnregisterRequest {
+	return RecurringUnregisterRequest{
+		Body: RecurringUnregisterRequestData{
+			CardToken:   cardToken,
+			RecurringID: recurringID,
+		},
+	}
+}
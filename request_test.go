@@ -0,0 +1,89 @@
+package bri
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// jsonKeys marshals v and returns its sorted top level keys, keys of direct debit request are taken from its body
+func jsonKeys(v interface{}) ([]string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if body, ok := fields["body"]; ok && len(fields) == 1 {
+		fields = nil
+		if err = json.Unmarshal(body, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (bri *BriSanguTestSuite) TestRequestJSONKeys() {
+	cases := []struct {
+		req  interface{}
+		keys []string
+	}{
+		{CreateVaRequest{}, []string{"amount", "brivaNo", "custCode", "expiredDate", "institutionCode", "keterangan", "nama"}},
+		{CreateVaRequest{AccountType: BRIVAAccountTypeStatic}, []string{"accountType", "amount", "brivaNo", "custCode", "expiredDate", "institutionCode", "keterangan", "nama"}},
+		{SimulatePaymentRequest{}, []string{"amount", "brivaNo", "custCode", "institutionCode"}},
+		{UpdateBRIVAExpiryRequest{}, []string{"brivaNo", "custCode", "expiredDate", "institutionCode"}},
+		{CardTokenOTPRequest{}, []string{"card_pan", "email", "otp_bri_status", "phone_number"}},
+		{CardTokenOTPVerifyRequest{}, []string{"passcode", "registration_token"}},
+		{PaymentChargeOTPRequest{}, []string{"amount", "card_token", "currency", "metadata", "otp_bri_status", "remarks"}},
+		{PaymentChargeOTPRequest{Body: PaymentChargeOTPRequestData{Installment: &Installment{}}}, []string{"amount", "card_token", "currency", "installment", "metadata", "otp_bri_status", "remarks"}},
+		{PaymentChargeOTPRequest{Body: PaymentChargeOTPRequestData{ThreeDSecure: true, ReturnURL: "https://merchant/return"}}, []string{"amount", "card_token", "currency", "metadata", "otp_bri_status", "remarks", "return_url", "three_ds"}},
+		{PaymentChargeOTPVerifyRequest{}, []string{"card_token", "charge_token", "passcode"}},
+		{DeleteCardTokenRequest{}, []string{"card_token"}},
+		{ChargeDetailRequest{}, []string{"metadata", "payment_id", "remarks"}},
+		{RefundRequest{}, []string{"amount", "card_token", "currency", "metadata", "payment_id", "reason"}},
+		{RefundStatusRequest{}, []string{"refund_id"}},
+		{CardTokenStatusRequest{}, []string{"card_token"}},
+		{ResendOTPRequest{}, []string{"registration_token"}},
+		{CancelChargeRequest{}, []string{"payment_id", "reason"}},
+		{ListCardTokensRequest{}, []string{"email", "phone_number"}},
+		{RecurringRegisterRequest{}, []string{"amount", "card_token", "currency", "frequency", "metadata", "remarks", "start_date"}},
+		{RecurringUnregisterRequest{}, []string{"card_token", "recurring_id"}},
+		{GetMutationRequest{}, []string{"accountNumber", "endDate", "startDate"}},
+		{AccountStatementRequest{}, []string{"accountNumber", "endDate", "startDate"}},
+		{AccountStatementRequest{Page: 2, Limit: 10}, []string{"accountNumber", "endDate", "limit", "page", "startDate"}},
+		{QRISRequest{}, []string{"amount", "merchantId", "referenceNo"}},
+		{QRISStatusRequest{}, []string{"merchantId", "referenceNo"}},
+		{CancelQRISRequest{}, []string{"merchantId", "referenceNo"}},
+		{SnapTokenRequest{}, []string{"grantType"}},
+		{IntrabankTransferRequest{}, []string{"Amount", "FeeType", "NoReferral", "beneficiaryAccount", "remark", "sourceAccount", "transactionDateTime"}},
+		{InterbankTransferRequest{}, []string{"Amount", "bankCode", "beneficiaryAccount", "beneficiaryAccountName", "noReferral", "remark", "sourceAccount", "transactionDateTime"}},
+		{BulkTransferRequest{}, []string{"items", "sourceAccount", "transactionDateTime"}},
+		{BulkTransferStatusRequest{}, []string{"batchReference"}},
+		{TransferStatusRequest{}, []string{"noReferral", "transactionDate"}},
+	}
+
+	for _, c := range cases {
+		keys, err := jsonKeys(c.req)
+		assert.Equal(bri.T(), nil, err)
+		assert.Equal(bri.T(), c.keys, keys, "%T", c.req)
+
+		// round trip
+		data, _ := json.Marshal(c.req)
+		decoded := reflect.New(reflect.TypeOf(c.req))
+		assert.Equal(bri.T(), nil, json.Unmarshal(data, decoded.Interface()))
+		assert.Equal(bri.T(), c.req, decoded.Elem().Interface(), "%T", c.req)
+	}
+}
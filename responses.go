@@ -1,9 +1,54 @@
 package bri
 
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// RawResponse holds BRI http response data which is not part of the decoded response body
+type RawResponse struct {
+	StatusCode int
+	Header     http.Header
+	// Duration is time taken by the call including retries, measured until response header is received
+	Duration time.Duration
+}
+
+// ResponseMeta is embedded in every response struct to give access to BRI http response
+type ResponseMeta struct {
+	raw   *RawResponse
+	extra map[string]json.RawMessage
+}
+
+// RawResponse returns BRI http response status code and headers, nil if the request was not sent
+func (m *ResponseMeta) RawResponse() *RawResponse {
+	return m.raw
+}
+
+func (m *ResponseMeta) setRawResponse(raw *RawResponse) {
+	m.raw = raw
+}
+
+// Extra returns response fields which are not modelled by the response struct, keyed by dotted path, e.g. "body.new_field".
+// It gives access to field newly added by BRI before the library supports it.
+func (m *ResponseMeta) Extra() map[string]json.RawMessage {
+	return m.extra
+}
+
+func (m *ResponseMeta) setExtra(extra map[string]json.RawMessage) {
+	m.extra = extra
+}
+
+// rawResponseSetter is implemented by response struct which embeds ResponseMeta
+type rawResponseSetter interface {
+	setRawResponse(raw *RawResponse)
+}
+
 type TokenResponse struct {
 	AccessToken string   `json:"access_token"`
 	ExpiredTime string   `json:"expires_in"`
 	ProductList []string `json:"api_product_list_json"`
+	ResponseMeta
 }
 
 type VaResponse struct {
@@ -12,6 +57,27 @@ type VaResponse struct {
 	ResponseDescription string `json:"responseDescription"`
 	ErrDesc             string `json:"errDesc"`
 	Data                VaData `json:"data"`
+	ResponseMeta
+}
+
+// UpdateBRIVAExpiryResponse defines response for BRIVA - update expiry
+type UpdateBRIVAExpiryResponse struct {
+	Status              bool   `json:"status"`
+	ResponseCode        string `json:"responseCode"`
+	ResponseDescription string `json:"responseDescription"`
+	ErrDesc             string `json:"errDesc"`
+	Data                VaData `json:"data"`
+	ResponseMeta
+}
+
+// SimulatePaymentResponse defines response for BRIVA - simulate payment (sandbox only)
+type SimulatePaymentResponse struct {
+	Status              bool   `json:"status"`
+	ResponseCode        string `json:"responseCode"`
+	ResponseDescription string `json:"responseDescription"`
+	ErrDesc             string `json:"errDesc"`
+	Data                VaData `json:"data"`
+	ResponseMeta
 }
 
 type VaData struct {
@@ -19,7 +85,7 @@ type VaData struct {
 	BrivaNo         string `json:"brivaNo"`
 	CustCode        string `json:"custCode"`
 	Name            string `json:"nama"`
-	Amount          string `json:"amount"`
+	Amount          Money  `json:"amount"`
 	Description     string `json:"keterangan"`
 	ExpiredDate     string `json:"expiredDate"`
 }
@@ -30,40 +96,82 @@ type VaReportResponse struct {
 	Description  string         `json:"responseDescription"`
 	ErrDesc      string         `json:"errDesc"`
 	Data         []VaReportData `json:"data"`
+	ResponseMeta
 }
 
 type VaReportData struct {
 	BrivaNo     string `json:"brivaNo"`
 	CustCode    string `json:"custCode"`
 	Nama        string `json:"nama"`
-	Amount      string `json:"amount"`
+	Amount      Money  `json:"amount"`
 	Description string `json:"keterangan"`
 	PaymentDate string `json:"paymentDate"`
 	TellerId    string `json:"tellerid"`
 	AccountNo   string `json:"no_rek"`
 }
 
+// PaymentsByVA groups report data by VA (BrivaNo + CustCode). Static VA may have multiple payment records in the report.
+func (r VaReportResponse) PaymentsByVA() map[string][]VaReportData {
+	payments := map[string][]VaReportData{}
+	for _, data := range r.Data {
+		key := data.BrivaNo + data.CustCode
+		payments[key] = append(payments[key], data)
+	}
+
+	return payments
+}
+
 // CardTokenOTPResponse defines response for direct debit - create card token OTP
 type CardTokenOTPResponse struct {
 	Body CardTokenOTPResponseData `json:"body"`
 	ErrorResponse
+	ResponseMeta
 }
 
 // CardTokenOTPResponseData defines data response for direct debit - create card token OTP
 type CardTokenOTPResponseData struct {
-	Status string `json:"status"`
-	Token  string `json:"token"`
+	Status StatusCode `json:"status"`
+	Token  string     `json:"token"`
+}
+
+// StatusCode is status value of direct debit response.
+// Body.Status holds StatusCodeSuccess or StatusCodeOTPRequired, while Body.PaymentStatus and Body.RefundStatus
+// hold transaction status StatusCodePaymentSuccess, StatusCodePending or StatusCodeFailed.
+// Error codes are compared using ErrorResponse.Code.
+type StatusCode string
+
+// Direct debit status codes
+const (
+	StatusCodeSuccess     StatusCode = "0000"
+	StatusCodeOTPRequired StatusCode = "PENDING_USER_VERIFICATION"
+
+	StatusCodePaymentSuccess StatusCode = "SUCCESS"
+	StatusCodePending        StatusCode = "PENDING"
+	StatusCodeFailed         StatusCode = "FAILED"
+
+	StatusCodeExpiredOTP          StatusCode = "0920"
+	StatusCodeInvalidOTP          StatusCode = "0921"
+	StatusCodeInsufficientBalance StatusCode = "0303"
+)
+
+// DirectDebitStatusPendingUserVerification is direct debit status if OTP has been sent and needs to be verified
+const DirectDebitStatusPendingUserVerification = StatusCodeOTPRequired
+
+// RequiresOTP reports whether OTP has been sent to the customer and binding needs to be verified using CreateCardTokenOTPVerify
+func (r CardTokenOTPResponse) RequiresOTP() bool {
+	return r.Body.Status == DirectDebitStatusPendingUserVerification
 }
 
 // CardTokenOTPVerifyResponse defines response for direct debit - create card token OTP verify
 type CardTokenOTPVerifyResponse struct {
 	Body CardTokenOTPVerifyResponseData `json:"body"`
 	ErrorResponse
+	ResponseMeta
 }
 
 // CardTokenOTPVerifyResponseData defines data response for direct debit - create card token OTP verify
 type CardTokenOTPVerifyResponseData struct {
-	Status           string                 `json:"status"`
+	Status           StatusCode             `json:"status"`
 	PhoneNumber      string                 `json:"phone_number"`
 	DeviceID         string                 `json:"device_id"`
 	CardToken        string                 `json:"card_token"`
@@ -94,6 +202,39 @@ type ErrorResponse struct {
 	Status     ErrorStatus `json:"status"`
 }
 
+// Code returns error code as StatusCode, e.g. to compare it with StatusCodeInvalidOTP
+func (r ErrorResponse) Code() StatusCode {
+	return StatusCode(r.Error.Code)
+}
+
+// errorResponder is implemented by response which embeds ErrorResponse
+type errorResponder interface {
+	errorResponse() ErrorResponse
+}
+
+func (r ErrorResponse) errorResponse() ErrorResponse {
+	return r
+}
+
+// briError returns *BRIError if response contains error, otherwise nil
+func (r ErrorResponse) briError() error {
+	if r.Error.Code == "" && r.Status.Code == "" {
+		return nil
+	}
+
+	err := &BRIError{
+		StatusCode: r.StatusCode,
+		Code:       r.Error.Code,
+		Message:    r.Error.Message,
+	}
+	if err.Code == "" {
+		err.Code = r.Status.Code
+		err.Message = r.Status.Desc
+	}
+
+	return err
+}
+
 // ErrorDetail defines response error detail. Example:
 // {
 //     "error": {
@@ -104,6 +245,8 @@ type ErrorResponse struct {
 type ErrorDetail struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// RemainingAttempts is number of OTP attempts left, only returned on wrong OTP
+	RemainingAttempts int `json:"remaining_attempts,omitempty"`
 }
 
 // ErrorStatus defines error data if unauthorized. Example:
@@ -122,63 +265,93 @@ type ErrorStatus struct {
 type PaymentChargeResponse struct {
 	Body PaymentChargeResponseData `json:"body"`
 	ErrorResponse
+	ResponseMeta
 }
 
 // PaymentChargeResponseData defines data response for direct debit - create payment charge [using OTP or not]
 type PaymentChargeResponseData struct {
-	Status        string                 `json:"status"`
+	Status        StatusCode             `json:"status"`
 	ChargeToken   string                 `json:"charge_token"`
 	PaymentID     string                 `json:"payment_id"`
-	Amount        string                 `json:"amount"`
+	Amount        Money                  `json:"amount"`
 	Currency      string                 `json:"currency"`
 	Remarks       string                 `json:"remarks"`
 	DeviceID      string                 `json:"device_id"`
-	PaymentStatus string                 `json:"payment_status"`
+	PaymentStatus StatusCode             `json:"payment_status"`
 	Location      Location               `json:"location"`
 	Metadata      map[string]interface{} `json:"metadata"`
+
+	// Fee is deducted by BRI from the charge, NetAmount is settled to merchant. Both are zero if BRI doesn't return them.
+	Fee       Money `json:"fee"`
+	NetAmount Money `json:"net_amount"`
+
+	// ThreeDSRedirectURL and ThreeDSStatus are only set if 3-D Secure is requested, ECI is the authentication result
+	ThreeDSRedirectURL string `json:"three_ds_redirect_url"`
+	ThreeDSStatus      string `json:"three_ds_status"`
+	ECI                string `json:"eci"`
+}
+
+// RequiresThreeDS reports whether the customer has to be redirected to Body.ThreeDSRedirectURL to authenticate the charge
+func (r PaymentChargeResponse) RequiresThreeDS() bool {
+	return r.Body.ThreeDSRedirectURL != ""
+}
+
+// RequiresOTP reports whether OTP has been sent to the customer and charge needs to be verified using CreatePaymentChargeOTPVerify.
+// False means no OTP is pending, check Body.PaymentStatus for the charge result.
+func (r PaymentChargeResponse) RequiresOTP() bool {
+	return r.Body.Status == DirectDebitStatusPendingUserVerification && r.Body.ChargeToken != ""
 }
 
 // DeleteCardTokenResponse defines response for direct debit - delete card token
 type DeleteCardTokenResponse struct {
 	Body DeleteCardTokenResponseData `json:"body"`
 	ErrorResponse
+	ResponseMeta
 }
 
 // DeleteCardTokenResponseData defines data response for direct debit - delete card token
 type DeleteCardTokenResponseData struct {
-	Status string `json:"status"`
+	Status StatusCode `json:"status"`
 }
 
 // ChargeDetailResponse defines response for direct debit - charge detail
 type ChargeDetailResponse struct {
 	Body ChargeDetailResponseData `json:"body"`
 	ErrorResponse
+	ResponseMeta
 }
 
 // ChargeDetailResponseData defines data response for direct debit - charge detail
 type ChargeDetailResponseData struct {
-	Status          string                 `json:"status"`
-	Amount          string                 `json:"amount"`
+	Status          StatusCode             `json:"status"`
+	Amount          Money                  `json:"amount"`
 	Currency        string                 `json:"currency"`
 	PaymentID       string                 `json:"payment_id"`
+	CardToken       string                 `json:"card_token"`
+	Remarks         string                 `json:"remarks"`
 	RemarksMerchant string                 `json:"remarks_merchant"`
-	PaymentStatus   string                 `json:"payment_status"`
+	PaymentStatus   StatusCode             `json:"payment_status"`
 	RefundHistory   []RefundResponseData   `json:"refund_history"`
 	DeviceID        string                 `json:"device_id"`
 	Location        Location               `json:"location"`
 	Metadata        map[string]interface{} `json:"metadata"`
+	Date            string                 `json:"date"`
+
+	// Fee is deducted by BRI from the charge, NetAmount is settled to merchant. Both are zero if BRI doesn't return them.
+	Fee       Money `json:"fee"`
+	NetAmount Money `json:"net_amount"`
 }
 
 // RefundResponseData defines data response for direct debit - refund
 type RefundResponseData struct {
-	Status       string                 `json:"status"`
+	Status       StatusCode             `json:"status"`
 	RefundID     string                 `json:"refund_id"`
 	PaymentID    string                 `json:"payment_id"`
-	Amount       string                 `json:"amount"`
-	Fee          string                 `json:"fee"`
+	Amount       Money                  `json:"amount"`
+	Fee          Money                  `json:"fee"`
 	Currency     string                 `json:"currency"`
 	Reason       string                 `json:"reason"`
-	RefundStatus string                 `json:"refund_status"`
+	RefundStatus StatusCode             `json:"refund_status"`
 	DeviceID     string                 `json:"device_id"`
 	Location     Location               `json:"location"`
 	Metadata     map[string]interface{} `json:"metadata"`
@@ -189,6 +362,7 @@ type RefundResponseData struct {
 type RefundResponse struct {
 	Body RefundResponseData `json:"body"`
 	ErrorResponse
+	ResponseMeta
 }
 
 // MutationRespCode is BRI API response code standard ( more at https://developers.bri.co.id/id/docs/account-statementv20 )
@@ -199,6 +373,7 @@ type MutationResponse struct {
 	ResponseDescription string         `json:"responseDescription"`
 	ErrDesc             string         `json:"errDesc"`
 	Data                []MutationData `json:"data"`
+	ResponseMeta
 }
 
 type MutationData struct {
@@ -210,3 +385,354 @@ type MutationData struct {
 	StartBalance    string `json:"startBalance"`
 	EndBalance      string `json:"endBalance"`
 }
+
+// AccountStatementResponse defines response for account statement (mutation) inquiry
+type AccountStatementResponse struct {
+	ResponseCode        string                  `json:"responseCode"`
+	ResponseDescription string                  `json:"responseDescription"`
+	ErrDesc             string                  `json:"errDesc"`
+	Data                []AccountStatementEntry `json:"data"`
+	ResponseMeta
+}
+
+// AccountStatementEntry defines a single debit or credit entry of account statement.
+// Only one of DebitAmount and CreditAmount is non zero, StartBalance and EndBalance are the running balance around the entry.
+type AccountStatementEntry struct {
+	TransactionTime string `json:"transactionTime"`
+	DebitAmount     Money  `json:"debitAmount"`
+	CreditAmount    Money  `json:"creditAmount"`
+	TypeAmount      string `json:"typeAmount"`
+	Remark          string `json:"remark"`
+	StartBalance    Money  `json:"startBalance"`
+	EndBalance      Money  `json:"endBalance"`
+}
+
+// QRISResponse defines response for QRIS - generate dynamic QR
+type QRISResponse struct {
+	ResponseCode        string   `json:"responseCode"`
+	ResponseDescription string   `json:"responseDescription"`
+	ErrDesc             string   `json:"errDesc"`
+	Data                QRISData `json:"data"`
+	ResponseMeta
+}
+
+// QRISData defines data response for QRIS - generate dynamic QR
+type QRISData struct {
+	MerchantID  string `json:"merchantId"`
+	ReferenceNo string `json:"referenceNo"`
+	Amount      Money  `json:"amount"`
+	QRContent   string `json:"qrContent"`
+	QRImageURL  string `json:"qrImageUrl"`
+	ExpiredDate string `json:"expiredDate"`
+}
+
+// QRISStatusResponse defines response for QRIS - payment status inquiry
+type QRISStatusResponse struct {
+	ResponseCode        string         `json:"responseCode"`
+	ResponseDescription string         `json:"responseDescription"`
+	ErrDesc             string         `json:"errDesc"`
+	Data                QRISStatusData `json:"data"`
+	ResponseMeta
+}
+
+// QRISStatusData defines data response for QRIS - payment status inquiry.
+// Status is one of QRISStatusPaid, QRISStatusUnpaid or QRISStatusExpired.
+type QRISStatusData struct {
+	ReferenceNo string `json:"referenceNo"`
+	Status      string `json:"status"`
+	PaidAmount  Money  `json:"paidAmount"`
+	PaymentDate string `json:"paymentDate"`
+}
+
+// CancelQRISResponse defines response for QRIS - cancel unpaid QR
+type CancelQRISResponse struct {
+	ResponseCode        string         `json:"responseCode"`
+	ResponseDescription string         `json:"responseDescription"`
+	ErrDesc             string         `json:"errDesc"`
+	Data                CancelQRISData `json:"data"`
+	ResponseMeta
+}
+
+// CancelQRISData defines data response for QRIS - cancel unpaid QR, Status is QRISStatusCancelled on success
+type CancelQRISData struct {
+	ReferenceNo string `json:"referenceNo"`
+	Status      string `json:"status"`
+}
+
+// SnapTokenResponse defines response for SNAP BI - access token B2B
+type SnapTokenResponse struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+	AccessToken     string `json:"accessToken"`
+	TokenType       string `json:"tokenType"`
+	ExpiresIn       string `json:"expiresIn"`
+	ResponseMeta
+}
+
+// SnapResponseStatus defines responseCode and responseMessage of every SNAP BI response. ResponseCode is 7 digits of
+// http status, service code and case code, e.g. "2001700" is success of transfer credit and "4031714" is insufficient fund.
+type SnapResponseStatus struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+}
+
+func (s *SnapResponseStatus) snapResponseStatus() *SnapResponseStatus {
+	return s
+}
+
+// snapResponseStatusGetter is implemented by response which embeds SnapResponseStatus
+type snapResponseStatusGetter interface {
+	snapResponseStatus() *SnapResponseStatus
+}
+
+// BalanceRespCodeInvalidAccount is BRI response code if the inquired account number is rejected
+const BalanceRespCodeInvalidAccount = "0102"
+
+// BalanceInquiryResponse defines response for account balance inquiry
+type BalanceInquiryResponse struct {
+	ResponseCode        string             `json:"responseCode"`
+	ResponseDescription string             `json:"responseDescription"`
+	ErrDesc             string             `json:"errDesc"`
+	Data                BalanceInquiryData `json:"data"`
+	ResponseMeta
+}
+
+// BalanceInquiryData defines data response for account balance inquiry
+type BalanceInquiryData struct {
+	AccountNumber    string `json:"accountNumber"`
+	AccountName      string `json:"accountName"`
+	Currency         string `json:"currency"`
+	AvailableBalance string `json:"availableBalance"`
+	CurrentBalance   string `json:"currentBalance"`
+	Status           string `json:"status"`
+}
+
+// TransferResponse defines response for fund transfer - intrabank and interbank
+type TransferResponse struct {
+	ResponseCode        string       `json:"responseCode"`
+	ResponseDescription string       `json:"responseDescription"`
+	ErrorDescription    string       `json:"errorDescription"`
+	Data                TransferData `json:"data"`
+	ResponseMeta
+}
+
+// TransferData defines data response for fund transfer - intrabank and interbank
+type TransferData struct {
+	NoReferral string `json:"noReferral"`
+	JournalSeq string `json:"journalSeq"`
+	Status     string `json:"status"`
+	// Fee is charged by BRI on top of the amount, e.g. interbank transfer fee. It is zero if BRI doesn't return it.
+	Fee Money `json:"fee"`
+}
+
+// TransferStatusResponse defines response for fund transfer - status inquiry
+type TransferStatusResponse struct {
+	ResponseCode        string             `json:"responseCode"`
+	ResponseDescription string             `json:"responseDescription"`
+	ErrorDescription    string             `json:"errorDescription"`
+	Data                TransferStatusData `json:"data"`
+	ResponseMeta
+}
+
+// TransferStatusData defines data response for fund transfer - status inquiry.
+// Status is one of TransferStatusSuccess, TransferStatusPending or TransferStatusFailed.
+type TransferStatusData struct {
+	NoReferral    string `json:"noReferral"`
+	JournalSeq    string `json:"journalSeq"`
+	Amount        Money  `json:"amount"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failureReason"`
+	// Fee is charged by BRI on top of the amount, e.g. interbank transfer fee. It is zero if BRI doesn't return it.
+	Fee Money `json:"fee"`
+}
+
+// BulkTransferResponse defines response for fund transfer - bulk transfer
+type BulkTransferResponse struct {
+	ResponseCode        string           `json:"responseCode"`
+	ResponseDescription string           `json:"responseDescription"`
+	ErrorDescription    string           `json:"errorDescription"`
+	Data                BulkTransferData `json:"data"`
+	ResponseMeta
+}
+
+// BulkTransferData defines data response for fund transfer - bulk transfer and its status inquiry.
+// Status of the batch and its items is one of TransferStatusSuccess, TransferStatusPending or TransferStatusFailed.
+type BulkTransferData struct {
+	BatchReference string                   `json:"batchReference"`
+	Status         string                   `json:"status"`
+	Items          []BulkTransferItemStatus `json:"items"`
+}
+
+// BulkTransferItemStatus defines status of a bulk transfer item
+type BulkTransferItemStatus struct {
+	NoReferral    string `json:"noReferral"`
+	JournalSeq    string `json:"journalSeq"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failureReason"`
+	Fee           Money  `json:"fee"`
+}
+
+// BulkTransferStatusResponse defines response for fund transfer - bulk transfer status inquiry
+type BulkTransferStatusResponse struct {
+	ResponseCode        string           `json:"responseCode"`
+	ResponseDescription string           `json:"responseDescription"`
+	ErrorDescription    string           `json:"errorDescription"`
+	Data                BulkTransferData `json:"data"`
+	ResponseMeta
+}
+
+// AccountInquiryRespCodeNotFound is BRI response code if the inquired beneficiary account is not found
+const AccountInquiryRespCodeNotFound = "0105"
+
+// AccountInquiryResponse defines response for beneficiary account inquiry
+type AccountInquiryResponse struct {
+	ResponseCode        string             `json:"responseCode"`
+	ResponseDescription string             `json:"responseDescription"`
+	ErrorDescription    string             `json:"errorDescription"`
+	Data                AccountInquiryData `json:"data"`
+	ResponseMeta
+}
+
+// AccountInquiryData defines data response for beneficiary account inquiry
+type AccountInquiryData struct {
+	BankCode           string `json:"bankCode"`
+	BeneficiaryAccount string `json:"beneficiaryAccount"`
+	AccountName        string `json:"beneficiaryAccountName"`
+}
+
+// ListBanksResponse defines response for interbank transfer - bank list
+type ListBanksResponse struct {
+	ResponseCode        string `json:"responseCode"`
+	ResponseDescription string `json:"responseDescription"`
+	ErrorDescription    string `json:"errorDescription"`
+	Data                []Bank `json:"data"`
+	ResponseMeta
+}
+
+// Bank defines destination bank of interbank transfer
+type Bank struct {
+	BankCode string `json:"bankCode"`
+	BankName string `json:"bankName"`
+}
+
+// Card token binding status
+const (
+	CardTokenStatusActive  = "ACTIVE"
+	CardTokenStatusExpired = "EXPIRED"
+	CardTokenStatusRevoked = "REVOKED"
+)
+
+// CardTokenStatusResponse defines response for direct debit - card token status inquiry
+type CardTokenStatusResponse struct {
+	Body CardTokenStatusResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// CardTokenStatusResponseData defines data response for direct debit - card token status inquiry
+type CardTokenStatusResponseData struct {
+	Status      StatusCode `json:"status"`
+	CardToken   string     `json:"card_token"`
+	TokenStatus string     `json:"token_status"`
+	Last4       string     `json:"last4"`
+	ExpiredAt   string     `json:"expired_at"`
+}
+
+// DirectDebitErrCodeOTPResendLimit is BRI error code if OTP has been resent too many times
+const DirectDebitErrCodeOTPResendLimit = "0923"
+
+// ResendOTPResponse defines response for direct debit - resend card token OTP
+type ResendOTPResponse struct {
+	Body ResendOTPResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// ResendOTPResponseData defines data response for direct debit - resend card token OTP
+type ResendOTPResponseData struct {
+	Status StatusCode `json:"status"`
+	Token  string     `json:"token"`
+}
+
+// DirectDebitErrCodeChargeSettled is BRI error code if the charge to be cancelled is already settled
+const DirectDebitErrCodeChargeSettled = "0315"
+
+// CancelChargeResponse defines response for direct debit - cancel charge
+type CancelChargeResponse struct {
+	Body CancelChargeResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// CancelChargeResponseData defines data response for direct debit - cancel charge
+type CancelChargeResponseData struct {
+	Status        StatusCode `json:"status"`
+	PaymentID     string     `json:"payment_id"`
+	PaymentStatus StatusCode `json:"payment_status"`
+}
+
+// ListCardTokensResponse defines response for direct debit - list card tokens
+type ListCardTokensResponse struct {
+	Body ListCardTokensResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// ListCardTokensResponseData defines data response for direct debit - list card tokens
+type ListCardTokensResponseData struct {
+	Status     StatusCode      `json:"status"`
+	CardTokens []CardTokenData `json:"card_tokens"`
+}
+
+// CardTokenData defines card token binding data
+type CardTokenData struct {
+	CardToken     string `json:"card_token"`
+	MaskedCardPan string `json:"masked_card_pan"`
+	Last4         string `json:"last4"`
+	CardType      string `json:"card_type"`
+	ExpiredAt     string `json:"expired_at"`
+	TokenStatus   string `json:"token_status"`
+}
+
+// DirectDebitErrCodeRefundNotFound is BRI error code if the inquired refund doesn't exist
+const DirectDebitErrCodeRefundNotFound = "0302"
+
+// RefundStatusResponse defines response for direct debit - refund status inquiry.
+// Body.Amount is the refunded amount.
+type RefundStatusResponse struct {
+	Body RefundResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// RecurringRegisterResponse defines response for direct debit - register recurring
+type RecurringRegisterResponse struct {
+	Body RecurringRegisterResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// RecurringRegisterResponseData defines data response for direct debit - register recurring
+type RecurringRegisterResponseData struct {
+	Status      StatusCode `json:"status"`
+	RecurringID string     `json:"recurring_id"`
+	CardToken   string     `json:"card_token"`
+	Amount      Money      `json:"amount"`
+	Currency    string     `json:"currency"`
+	Frequency   string     `json:"frequency"`
+	StartDate   string     `json:"start_date"`
+	NextDate    string     `json:"next_date"`
+}
+
+// RecurringUnregisterResponse defines response for direct debit - unregister recurring
+type RecurringUnregisterResponse struct {
+	Body RecurringUnregisterResponseData `json:"body"`
+	ErrorResponse
+	ResponseMeta
+}
+
+// RecurringUnregisterResponseData defines data response for direct debit - unregister recurring
+type RecurringUnregisterResponseData struct {
+	Status      StatusCode `json:"status"`
+	RecurringID string     `json:"recurring_id"`
+}
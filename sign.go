@@ -0,0 +1,87 @@
+package bri
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// requestSigner re-signs a request when it is sent again by the retrier, so the retry doesn't replay an expired timestamp
+type requestSigner struct {
+	attempts int32
+	resign   func(req *http.Request)
+}
+
+type requestSignerKey struct{}
+
+// withSignPath binds path, which is the path covered by the request signature, to ctx.
+// The request sent with ctx gets a fresh timestamp and signature on every retry attempt.
+func (c *Client) withSignPath(ctx context.Context, path string) context.Context {
+	client := *c
+	return context.WithValue(ctx, requestSignerKey{}, &requestSigner{
+		resign: func(req *http.Request) {
+			client.resign(req, path)
+		},
+	})
+}
+
+// resignRetry re-signs req if it has been sent before, it is called on every attempt
+func resignRetry(req *http.Request) {
+	signer, ok := req.Context().Value(requestSignerKey{}).(*requestSigner)
+	if !ok {
+		return
+	}
+
+	if atomic.AddInt32(&signer.attempts, 1) > 1 {
+		signer.resign(req)
+	}
+}
+
+// resign replaces timestamp and signature headers of req with fresh ones. Signing scheme is detected from the headers:
+// BRI-Signature (core), X-BRI-Signature (direct debit), X-SIGNATURE with X-CLIENT-KEY (SNAP BI access token)
+// and X-SIGNATURE with Authorization (SNAP BI transaction). Requests without signature are left as is.
+func (c *Client) resign(req *http.Request, path string) {
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return
+		}
+		body, err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	token := req.Header.Get("Authorization")
+
+	switch {
+	case req.Header.Get("BRI-Signature") != "":
+		timestamp := c.timestamp(BRI_TIME_FORMAT)
+		req.Header.Set("BRI-Timestamp", timestamp)
+		req.Header.Set("BRI-Signature", c.signature(path, req.Method, token, timestamp, string(body)))
+	case req.Header.Get("X-BRI-Signature") != "":
+		timestamp := c.timestamp(BRI_TIME_FORMAT)
+		req.Header.Set("BRI-Timestamp", timestamp)
+		req.Header.Set("X-BRI-Signature", c.signature(path, req.Method, token, timestamp, string(body)))
+	case req.Header.Get("X-SIGNATURE") != "" && req.Header.Get("X-CLIENT-KEY") != "":
+		if c.PrivateKey == nil {
+			return
+		}
+		timestamp := c.snapTimestamp()
+		signature, err := GenerateSignatureAsymmetric(c.PrivateKey, req.Header.Get("X-CLIENT-KEY")+"|"+timestamp)
+		if err != nil {
+			return
+		}
+		req.Header.Set("X-TIMESTAMP", timestamp)
+		req.Header.Set("X-SIGNATURE", signature)
+	case req.Header.Get("X-SIGNATURE") != "" && token != "":
+		timestamp := c.snapTimestamp()
+		stringToSign := SnapStringToSign(req.Method, path, strings.TrimPrefix(token, "Bearer "), body, timestamp)
+		req.Header.Set("X-TIMESTAMP", timestamp)
+		req.Header.Set("X-SIGNATURE", GenerateSignatureSymmetric(c.ClientSecret, stringToSign))
+	}
+}
@@ -0,0 +1,261 @@
+package bri
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	SNAP_TOKEN_PATH  = "/snap/v1.0/access-token/b2b"
+	SNAP_TIME_FORMAT = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// snapLocation is Asia/Jakarta (WIB), SNAP BI expects X-TIMESTAMP in local time of Indonesia, e.g. 2021-11-02T13:14:15.678+07:00.
+// It is a fixed zone since Indonesia has no daylight saving time and tzdata may be missing on the host.
+var snapLocation = time.FixedZone("WIB", 7*60*60)
+
+// SnapGateway struct is used to call BRI API which follow Bank Indonesia SNAP (Standar Nasional Open API Pembayaran) standard.
+// Transactional API, e.g. transfer credit and balance inquiry, is in snap subpackage.
+type SnapGateway struct {
+	Client Client
+}
+
+// Call : base method to call SNAP BI API
+func (gateway *SnapGateway) Call(method, path string, header map[string]string, body io.Reader, v interface{}, vErr interface{}) error {
+	return gateway.call(context.Background(), method, path, header, body, v, vErr)
+}
+
+func (gateway *SnapGateway) call(ctx context.Context, method, path string, header map[string]string, body io.Reader, v interface{}, vErr interface{}) error {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if gateway.Client.BaseUrl == "" {
+		return ErrMissingBaseURL
+	}
+
+	ctx = gateway.Client.withSignPath(ctx, path)
+	path = strings.TrimSuffix(gateway.Client.BaseUrl, "/") + path
+
+	return gateway.Client.call(ctx, method, path, header, body, v, vErr)
+}
+
+// GetToken requests SNAP BI B2B access token. Request is signed asymmetrically using Client.PrivateKey.
+// It is bounded by Client.TokenTimeout if it is set.
+func (gateway *SnapGateway) GetToken() (res SnapTokenResponse, err error) {
+	if gateway.Client.PrivateKey == nil {
+		err = ErrMissingPrivateKey
+		return
+	}
+
+	if gateway.Client.ClientId == "" {
+		err = ErrMissingClientID
+		return
+	}
+
+	timestamp := gateway.Client.snapTimestamp()
+	signature, err := GenerateSignatureAsymmetric(gateway.Client.PrivateKey, gateway.Client.ClientId+"|"+timestamp)
+	if err != nil {
+		return
+	}
+
+	body, err := json.Marshal(SnapTokenRequest{GrantType: "client_credentials"})
+	if err != nil {
+		return
+	}
+
+	headers := map[string]string{
+		"X-SIGNATURE":  signature,
+		"X-CLIENT-KEY": gateway.Client.ClientId,
+		"X-TIMESTAMP":  timestamp,
+		"Content-Type": ContentTypeJSON,
+	}
+
+	ctx, cancel := gateway.Client.tokenContext(context.Background())
+	defer cancel()
+
+	err = gateway.call(ctx, http.MethodPost, SNAP_TOKEN_PATH, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// CallTransaction posts req to path as SNAP BI transactional request, signed symmetrically using Client.ClientSecret,
+// and decodes the response into res. token is SNAP BI access token, see GetToken.
+// externalID is sent as X-EXTERNAL-ID header and used by BRI as idempotency key, ErrMissingExternalID is returned if it is empty.
+// If res embeds SnapResponseStatus, *SnapError is returned when its responseCode is not success, res is still populated.
+func (gateway *SnapGateway) CallTransaction(ctx context.Context, token, path, externalID string, req interface{}, res interface{}) error {
+	if gateway.Client.PartnerID == "" {
+		return ErrMissingPartnerID
+	}
+
+	if gateway.Client.ClientSecret == "" {
+		return ErrMissingClientSecret
+	}
+
+	if externalID == "" {
+		return ErrMissingExternalID
+	}
+
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	timestamp := gateway.Client.snapTimestamp()
+	signature := GenerateSignatureSymmetric(gateway.Client.ClientSecret, SnapStringToSign(method, path, token, body, timestamp))
+
+	headers := gateway.snapHeaders(token, timestamp, signature, externalID)
+
+	// SNAP BI error body has the same responseCode and responseMessage as success body, so it is decoded into res
+	err = gateway.call(ctx, method, path, headers, bytes.NewReader(body), res, nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if r, ok := res.(snapResponseStatusGetter); ok {
+		if status := r.snapResponseStatus(); !strings.HasPrefix(status.ResponseCode, "2") {
+			return &SnapError{ResponseCode: status.ResponseCode, ResponseMessage: status.ResponseMessage}
+		}
+	}
+
+	return err
+}
+
+// snapHeaders returns headers of SNAP BI transactional request
+func (gateway *SnapGateway) snapHeaders(token, timestamp, signature, externalID string) map[string]string {
+	return map[string]string{
+		"Authorization": "Bearer " + token,
+		"X-TIMESTAMP":   timestamp,
+		"X-SIGNATURE":   signature,
+		"X-PARTNER-ID":  gateway.Client.PartnerID,
+		"X-EXTERNAL-ID": externalID,
+		"CHANNEL-ID":    gateway.Client.ChannelID,
+		"Content-Type":  ContentTypeJSON,
+	}
+}
+
+// GenerateSignatureAsymmetric signs stringToSign using SHA256withRSA, used by SNAP BI access token request.
+// For access token request, stringToSign is "client_id|timestamp".
+func GenerateSignatureAsymmetric(privateKey *rsa.PrivateKey, stringToSign string) (string, error) {
+	if privateKey == nil {
+		return "", ErrMissingPrivateKey
+	}
+
+	hashed := sha256.Sum256([]byte(stringToSign))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// SignSNAPToken signs SNAP BI access token request string "clientID|timestamp" using SHA256withRSA.
+// privateKeyPEM is PEM encoded PKCS#1 or PKCS#8 RSA private key.
+func SignSNAPToken(clientID string, timestamp string, privateKeyPEM []byte) (string, error) {
+	privateKey, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+
+	return GenerateSignatureAsymmetric(privateKey, clientID+"|"+timestamp)
+}
+
+// LoadPrivateKeyPEM reads PEM encoded PKCS#1 or PKCS#8 RSA private key file, e.g. to set Client.PrivateKey.
+// Encrypted key is not supported, decrypt it first.
+func LoadPrivateKeyPEM(path string) (*rsa.PrivateKey, error) {
+	privateKeyPEM, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePrivateKeyPEM(privateKeyPEM)
+}
+
+// LoadPrivateKeyFromEnv loads RSA private key from PEM content in EnvPrivateKey, or from file in EnvPrivateKeyPath.
+// Literal "\n" in EnvPrivateKey is treated as newline. ErrMissingEnv is returned if both are empty.
+func LoadPrivateKeyFromEnv() (*rsa.PrivateKey, error) {
+	if privateKeyPEM := os.Getenv(EnvPrivateKey); privateKeyPEM != "" {
+		return parsePrivateKeyPEM([]byte(strings.Replace(privateKeyPEM, `\n`, "\n", -1)))
+	}
+
+	if path := os.Getenv(EnvPrivateKeyPath); path != "" {
+		return LoadPrivateKeyPEM(path)
+	}
+
+	return nil, fmt.Errorf("%w: %s or %s", ErrMissingEnv, EnvPrivateKey, EnvPrivateKeyPath)
+}
+
+// parsePrivateKeyPEM parses PEM encoded PKCS#1 or PKCS#8 RSA private key
+func parsePrivateKeyPEM(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidPrivateKey)
+	}
+
+	if block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		return nil, fmt.Errorf("%w: encrypted key is not supported, decrypt it first", ErrInvalidPrivateKey)
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+		}
+		return key, nil
+	}
+
+	if block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%w: unexpected PEM block %q, expected RSA PRIVATE KEY or PRIVATE KEY", ErrInvalidPrivateKey, block.Type)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an RSA key", ErrInvalidPrivateKey)
+	}
+
+	return privateKey, nil
+}
+
+// GenerateSignatureSymmetric signs stringToSign using HMAC-SHA512, used by SNAP BI transactional request.
+// Use SnapStringToSign to build the stringToSign.
+func GenerateSignatureSymmetric(clientSecret, stringToSign string) string {
+	return GenerateHMACSignature(HMACSHA512, clientSecret, stringToSign)
+}
+
+// SnapStringToSign builds SNAP BI symmetric stringToSign with format:
+// HTTPMethod:EndpointUrl:AccessToken:Lowercase(HexEncode(SHA-256(Minify(RequestBody)))):TimeStamp
+func SnapStringToSign(method, path, accessToken string, body []byte, timestamp string) string {
+	minified := new(bytes.Buffer)
+	if err := json.Compact(minified, body); err != nil {
+		// body is not a json, sign it as is
+		minified.Reset()
+		minified.Write(body)
+	}
+
+	hashed := sha256.Sum256(minified.Bytes())
+
+	return method + ":" + path + ":" + accessToken + ":" + strings.ToLower(hex.EncodeToString(hashed[:])) + ":" + timestamp
+}
@@ -0,0 +1,29 @@
+package snap
+
+import bri "github.com/kitabisa/sangu-bri"
+
+// TransferCreditRequest defines payload for SNAP BI - transfer credit (disbursement)
+type TransferCreditRequest struct {
+	// ExternalID is sent as X-EXTERNAL-ID header and used by BRI as idempotency key, it is required
+	ExternalID string `json:"-"`
+
+	PartnerReferenceNo   string                 `json:"partnerReferenceNo"`
+	Amount               bri.SnapAmount         `json:"amount"`
+	BeneficiaryAccountNo string                 `json:"beneficiaryAccountNo"`
+	SourceAccountNo      string                 `json:"sourceAccountNo"`
+	TransactionDate      string                 `json:"transactionDate"`
+	FeeType              string                 `json:"feeType,omitempty"`
+	Remark               string                 `json:"remark,omitempty"`
+	AdditionalInfo       map[string]interface{} `json:"additionalInfo,omitempty"`
+}
+
+// BalanceInquiryRequest defines payload for SNAP BI - balance inquiry
+type BalanceInquiryRequest struct {
+	// ExternalID is sent as X-EXTERNAL-ID header, a unique one is generated if it is empty
+	ExternalID string `json:"-"`
+
+	PartnerReferenceNo string                 `json:"partnerReferenceNo,omitempty"`
+	AccountNo          string                 `json:"accountNo"`
+	BalanceTypes       []string               `json:"balanceTypes,omitempty"`
+	AdditionalInfo     map[string]interface{} `json:"additionalInfo,omitempty"`
+}
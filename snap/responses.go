@@ -0,0 +1,39 @@
+package snap
+
+import bri "github.com/kitabisa/sangu-bri"
+
+// TransferCreditResponse defines response for SNAP BI - transfer credit (disbursement)
+type TransferCreditResponse struct {
+	ReferenceNo          string                 `json:"referenceNo"`
+	PartnerReferenceNo   string                 `json:"partnerReferenceNo"`
+	Amount               bri.SnapAmount         `json:"amount"`
+	BeneficiaryAccountNo string                 `json:"beneficiaryAccountNo"`
+	SourceAccountNo      string                 `json:"sourceAccountNo"`
+	TransactionDate      string                 `json:"transactionDate"`
+	AdditionalInfo       map[string]interface{} `json:"additionalInfo"`
+	bri.SnapResponseStatus
+	bri.ResponseMeta
+}
+
+// BalanceInquiryResponse defines response for SNAP BI - balance inquiry
+type BalanceInquiryResponse struct {
+	ReferenceNo        string                 `json:"referenceNo"`
+	PartnerReferenceNo string                 `json:"partnerReferenceNo"`
+	AccountNo          string                 `json:"accountNo"`
+	Name               string                 `json:"name"`
+	AccountInfos       []AccountInfo          `json:"accountInfos"`
+	AdditionalInfo     map[string]interface{} `json:"additionalInfo"`
+	bri.SnapResponseStatus
+	bri.ResponseMeta
+}
+
+// AccountInfo defines balance of an account in SNAP BI balance inquiry response
+type AccountInfo struct {
+	BalanceType      string         `json:"balanceType"`
+	Amount           bri.SnapAmount `json:"amount"`
+	FloatAmount      bri.SnapAmount `json:"floatAmount"`
+	HoldAmount       bri.SnapAmount `json:"holdAmount"`
+	AvailableBalance bri.SnapAmount `json:"availableBalance"`
+	LedgerBalance    bri.SnapAmount `json:"ledgerBalance"`
+	Status           string         `json:"status"`
+}
@@ -0,0 +1,56 @@
+// Package snap calls BRI transactional API which follow Bank Indonesia SNAP (Standar Nasional Open API Pembayaran) standard,
+// e.g. transfer credit (disbursement). Its models follow SNAP BI specification, so they interoperate with other SNAP banks.
+//
+// Non success responseCode is returned as *bri.SnapError.
+//
+// Access token is requested using bri.SnapGateway.GetToken, requests are sent through the same bri.Client,
+// so they are re-signed on retry and honour the client setting, e.g. CircuitBreaker and DebugWriter.
+package snap
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	bri "github.com/kitabisa/sangu-bri"
+)
+
+const (
+	pathTransferCredit = "/intrabank/snap/v1.0/transfer-intrabank"
+	pathBalanceInquiry = "/snap/v1.0/balance-inquiry"
+)
+
+// Gateway struct is used to call SNAP BI transactional API.
+// Client.ClientSecret, Client.PartnerID and Client.ChannelID must be set.
+type Gateway struct {
+	Client bri.Client
+}
+
+// TransferCredit transfers fund (disbursement) to req.BeneficiaryAccountNo using SNAP BI transfer credit.
+// token is SNAP BI access token, see bri.SnapGateway.GetToken. req.ExternalID is required and must be unique per day,
+// so retrying a failed call will not send the fund twice.
+func (g *Gateway) TransferCredit(token string, req TransferCreditRequest) (res TransferCreditResponse, err error) {
+	snapGateway := bri.SnapGateway{
+		Client: g.Client,
+	}
+
+	err = snapGateway.CallTransaction(context.Background(), token, pathTransferCredit, req.ExternalID, req, &res)
+	return
+}
+
+// BalanceInquiry inquires balance of req.AccountNo using SNAP BI balance inquiry.
+// token is SNAP BI access token, see bri.SnapGateway.GetToken.
+func (g *Gateway) BalanceInquiry(token string, req BalanceInquiryRequest) (res BalanceInquiryResponse, err error) {
+	snapGateway := bri.SnapGateway{
+		Client: g.Client,
+	}
+
+	// balance inquiry is read only, so a generated external id is safe on retry
+	externalID := req.ExternalID
+	if externalID == "" {
+		externalID = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	err = snapGateway.CallTransaction(context.Background(), token, pathBalanceInquiry, externalID, req, &res)
+	return
+}
@@ -0,0 +1,109 @@
+package snap
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bri "github.com/kitabisa/sangu-bri"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGateway returns Gateway of mock SNAP BI server, it doesn't need BRI credential
+func newTestGateway(serverURL string) Gateway {
+	client := bri.NewClient()
+	client.LogLevel = 0
+	client.BaseUrl = serverURL
+	client.ClientSecret = "secret"
+	client.PartnerID = "partner"
+	client.ChannelID = "95221"
+
+	return Gateway{
+		Client: client,
+	}
+}
+
+func TestTransferCredit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		stringToSign := bri.SnapStringToSign(http.MethodPost, pathTransferCredit, "token", body, r.Header.Get("X-TIMESTAMP"))
+
+		assert.Equal(t, pathTransferCredit, r.URL.Path)
+		assert.Equal(t, bri.GenerateSignatureSymmetric("secret", stringToSign), r.Header.Get("X-SIGNATURE"))
+		assert.Equal(t, "partner", r.Header.Get("X-PARTNER-ID"))
+		assert.Equal(t, "20211102000001", r.Header.Get("X-EXTERNAL-ID"))
+		assert.Equal(t, `{"partnerReferenceNo":"ref-1","amount":{"value":"10000.00","currency":"IDR"},"beneficiaryAccountNo":"888801000157508",`+
+			`"sourceAccountNo":"888801000157610","transactionDate":"2021-11-02T13:14:15+07:00"}`, string(body))
+
+		w.Write([]byte(`{"responseCode":"2001700","responseMessage":"Successful","referenceNo":"123","partnerReferenceNo":"ref-1","amount":{"value":"10000.00","currency":"IDR"}}`))
+	}))
+	defer server.Close()
+
+	gateway := newTestGateway(server.URL)
+	req := TransferCreditRequest{
+		ExternalID:           "20211102000001",
+		PartnerReferenceNo:   "ref-1",
+		Amount:               bri.NewSnapAmount(bri.NewMoney(1000000, bri.CurrencyIDR)),
+		BeneficiaryAccountNo: "888801000157508",
+		SourceAccountNo:      "888801000157610",
+		TransactionDate:      "2021-11-02T13:14:15+07:00",
+	}
+	resp, err := gateway.TransferCredit("token", req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2001700", resp.ResponseCode)
+	assert.Equal(t, "123", resp.ReferenceNo)
+	assert.Equal(t, int64(1000000), resp.Amount.Value.Value)
+	assert.Equal(t, http.StatusOK, resp.RawResponse().StatusCode)
+
+	// disbursement without idempotency key is not sent
+	req.ExternalID = ""
+	_, err = gateway.TransferCredit("token", req)
+	assert.Equal(t, bri.ErrMissingExternalID, err)
+}
+
+func TestBalanceInquiry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		stringToSign := bri.SnapStringToSign(http.MethodPost, pathBalanceInquiry, "token", body, r.Header.Get("X-TIMESTAMP"))
+
+		assert.Equal(t, pathBalanceInquiry, r.URL.Path)
+		assert.Equal(t, bri.GenerateSignatureSymmetric("secret", stringToSign), r.Header.Get("X-SIGNATURE"))
+		assert.Equal(t, "partner", r.Header.Get("X-PARTNER-ID"))
+		assert.Equal(t, "95221", r.Header.Get("CHANNEL-ID"))
+		assert.NotEqual(t, "", r.Header.Get("X-EXTERNAL-ID"))
+
+		if string(body) == `{"accountNo":"unknown"}` {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"responseCode":"4041111","responseMessage":"Invalid Account"}`))
+			return
+		}
+		assert.Equal(t, `{"accountNo":"888801000157508"}`, string(body))
+
+		w.Write([]byte(`{"responseCode":"2001100","responseMessage":"Successful","accountNo":"888801000157508","name":"Kitabisa",` +
+			`"accountInfos":[{"balanceType":"Cash","availableBalance":{"value":"150000.00","currency":"IDR"},"floatAmount":{"value":"5000.00","currency":"IDR"}}]}`))
+	}))
+	defer server.Close()
+
+	gateway := newTestGateway(server.URL)
+	resp, err := gateway.BalanceInquiry("token", BalanceInquiryRequest{AccountNo: "888801000157508"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2001100", resp.ResponseCode)
+	assert.Equal(t, 1, len(resp.AccountInfos))
+	assert.Equal(t, int64(15000000), resp.AccountInfos[0].AvailableBalance.Value.Value)
+	assert.Equal(t, int64(500000), resp.AccountInfos[0].FloatAmount.Value.Value)
+
+	_, err = gateway.BalanceInquiry("token", BalanceInquiryRequest{AccountNo: "unknown"})
+	assert.Equal(t, &bri.SnapError{ResponseCode: "4041111", ResponseMessage: "Invalid Account"}, err)
+
+	gateway.Client.PartnerID = ""
+	_, err = gateway.BalanceInquiry("token", BalanceInquiryRequest{})
+	assert.Equal(t, bri.ErrMissingPartnerID, err)
+}
+
+func TestRequestJSON(t *testing.T) {
+	data, err := json.Marshal(TransferCreditRequest{ExternalID: "ignored"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"partnerReferenceNo":"","amount":{"value":"0.00","currency":""},"beneficiaryAccountNo":"","sourceAccountNo":"","transactionDate":""}`, string(data))
+}
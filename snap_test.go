@@ -0,0 +1,203 @@
+package bri
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestSnapGenerateSignatureAsymmetric() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Equal(bri.T(), nil, err)
+
+	stringToSign := bri.client.ClientId + "|2021-11-02T13:14:15.678+07:00"
+	sig, err := GenerateSignatureAsymmetric(privateKey, stringToSign)
+	assert.Equal(bri.T(), nil, err)
+
+	decoded, err := base64.StdEncoding.DecodeString(sig)
+	assert.Equal(bri.T(), nil, err)
+
+	hashed := sha256.Sum256([]byte(stringToSign))
+	err = rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hashed[:], decoded)
+	assert.Equal(bri.T(), nil, err)
+}
+
+func (bri *BriSanguTestSuite) TestSnapGenerateSignatureAsymmetricMissingKey() {
+	_, err := GenerateSignatureAsymmetric(nil, "client|timestamp")
+	assert.Equal(bri.T(), ErrMissingPrivateKey, err)
+}
+
+func (bri *BriSanguTestSuite) TestSignSNAPToken() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Equal(bri.T(), nil, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.Equal(bri.T(), nil, err)
+
+	pemBlocks := [][]byte{
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	}
+
+	timestamp := "2021-11-02T13:14:15.678+07:00"
+	hashed := sha256.Sum256([]byte("client|" + timestamp))
+	for _, privateKeyPEM := range pemBlocks {
+		sig, err := SignSNAPToken("client", timestamp, privateKeyPEM)
+		assert.Equal(bri.T(), nil, err)
+
+		decoded, err := base64.StdEncoding.DecodeString(sig)
+		assert.Equal(bri.T(), nil, err)
+
+		err = rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hashed[:], decoded)
+		assert.Equal(bri.T(), nil, err)
+	}
+
+	_, err = SignSNAPToken("client", timestamp, []byte("not a pem"))
+	assert.True(bri.T(), errors.Is(err, ErrInvalidPrivateKey))
+}
+
+func (bri *BriSanguTestSuite) TestSnapGenerateSignatureSymmetric() {
+	stringToSign := SnapStringToSign("POST", "/snap/v1.0/transfer-intrabank", "token", []byte(`{ "amount": "10000.00" }`), "2021-11-02T13:14:15.678+07:00")
+
+	assert.Equal(bri.T(), "POST:/snap/v1.0/transfer-intrabank:token:b796bc85abeb9568a38d3f52ffd9e9e9c55beb3aa1ae6e79aed76cb93fda00ff:2021-11-02T13:14:15.678+07:00", stringToSign)
+	assert.Equal(bri.T(), "GlJWCS+L0Vm4bpcAC9UPQLcmD3VuGRkpHaMqW2wk4MqEjse6XgAfryv0e6HLgHcrplevqZRWkwzdLkt/dVZSAQ==", GenerateSignatureSymmetric("secret", stringToSign))
+	assert.NotEqual(bri.T(), GenerateSignatureSymmetric("secret", stringToSign), GenerateSignatureSymmetric("other", stringToSign))
+}
+
+func (bri *BriSanguTestSuite) TestSnapCallTransaction() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		stringToSign := SnapStringToSign(http.MethodPost, "/snap/v1.0/transfer", "token", body, r.Header.Get("X-TIMESTAMP"))
+
+		assert.Equal(bri.T(), "/snap/v1.0/transfer", r.URL.Path)
+		assert.Equal(bri.T(), "2021-11-02T13:14:15.678+07:00", r.Header.Get("X-TIMESTAMP"))
+		assert.Equal(bri.T(), "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(bri.T(), GenerateSignatureSymmetric(bri.client.ClientSecret, stringToSign), r.Header.Get("X-SIGNATURE"))
+		assert.Equal(bri.T(), "partner", r.Header.Get("X-PARTNER-ID"))
+		assert.Equal(bri.T(), "95221", r.Header.Get("CHANNEL-ID"))
+		assert.Equal(bri.T(), `{"amount":{"value":"10000.00","currency":"IDR"}}`, string(body))
+
+		if r.Header.Get("X-EXTERNAL-ID") == "20211102000002" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"responseCode":"4001702","responseMessage":"Invalid Mandatory Field amount"}`))
+			return
+		}
+		assert.Equal(bri.T(), "20211102000001", r.Header.Get("X-EXTERNAL-ID"))
+		w.Write([]byte(`{"responseCode":"2001700","responseMessage":"Successful","amount":{"value":"10000.00","currency":"IDR"}}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	bri.client.PartnerID = "partner"
+	bri.client.ChannelID = "95221"
+	bri.client.Now = func() time.Time {
+		return time.Date(2021, 11, 2, 6, 14, 15, 678000000, time.UTC)
+	}
+	snapGateway := SnapGateway{
+		Client: bri.client,
+	}
+
+	type transaction struct {
+		Amount SnapAmount `json:"amount"`
+	}
+	type transactionResponse struct {
+		transaction
+		SnapResponseStatus
+	}
+	req := transaction{Amount: NewSnapAmount(NewMoney(1000000, "IDR"))}
+	var res transactionResponse
+	err := snapGateway.CallTransaction(context.Background(), "token", "/snap/v1.0/transfer", "20211102000001", req, &res)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "2001700", res.ResponseCode)
+	assert.Equal(bri.T(), int64(1000000), res.Amount.Value.Value)
+
+	// error body is decoded, but its non success responseCode is returned as error
+	res = transactionResponse{}
+	err = snapGateway.CallTransaction(context.Background(), "token", "/snap/v1.0/transfer", "20211102000002", req, &res)
+	assert.Equal(bri.T(), &SnapError{ResponseCode: "4001702", ResponseMessage: "Invalid Mandatory Field amount"}, err)
+	assert.Equal(bri.T(), http.StatusBadRequest, err.(*SnapError).HTTPStatus())
+	assert.Equal(bri.T(), "Invalid Mandatory Field amount", res.ResponseMessage)
+
+	// retrying without idempotency key could send the fund twice
+	err = snapGateway.CallTransaction(context.Background(), "token", "/snap/v1.0/transfer", "", req, &res)
+	assert.Equal(bri.T(), ErrMissingExternalID, err)
+
+	snapGateway.Client.PartnerID = ""
+	err = snapGateway.CallTransaction(context.Background(), "token", "/snap/v1.0/transfer", "20211102000001", req, &res)
+	assert.Equal(bri.T(), ErrMissingPartnerID, err)
+}
+
+func (bri *BriSanguTestSuite) TestLoadPrivateKey() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Equal(bri.T(), nil, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.Equal(bri.T(), nil, err)
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+
+	file, err := ioutil.TempFile("", "bri-key-*.pem")
+	assert.Equal(bri.T(), nil, err)
+	defer os.Remove(file.Name())
+	file.Write(privateKeyPEM)
+	file.Close()
+
+	key, err := LoadPrivateKeyPEM(file.Name())
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), 0, privateKey.D.Cmp(key.D))
+
+	for _, env := range []string{EnvPrivateKey, EnvPrivateKeyPath} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+
+	_, err = LoadPrivateKeyFromEnv()
+	assert.True(bri.T(), errors.Is(err, ErrMissingEnv))
+
+	os.Setenv(EnvPrivateKeyPath, file.Name())
+	key, err = LoadPrivateKeyFromEnv()
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), 0, privateKey.D.Cmp(key.D))
+
+	// PEM content takes precedence, escaped newline is accepted
+	os.Setenv(EnvPrivateKey, strings.Replace(string(privateKeyPEM), "\n", `\n`, -1))
+	os.Setenv(EnvPrivateKeyPath, "/nonexistent")
+	key, err = LoadPrivateKeyFromEnv()
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), 0, privateKey.D.Cmp(key.D))
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecPKCS8, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	invalid := map[string][]byte{
+		"encrypted":    pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8}),
+		"legacy":       pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Headers: map[string]string{"Proc-Type": "4,ENCRYPTED"}, Bytes: pkcs8}),
+		"unexpected":   pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkcs8}),
+		"not an RSA":   pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8}),
+		"no PEM block": []byte("not a pem"),
+	}
+	for message, data := range invalid {
+		_, err = parsePrivateKeyPEM(data)
+		assert.True(bri.T(), errors.Is(err, ErrInvalidPrivateKey), message)
+	}
+	_, err = parsePrivateKeyPEM(invalid["encrypted"])
+	assert.Contains(bri.T(), err.Error(), "encrypted")
+	_, err = parsePrivateKeyPEM(invalid["unexpected"])
+	assert.Contains(bri.T(), err.Error(), `unexpected PEM block "PUBLIC KEY"`)
+	_, err = parsePrivateKeyPEM(invalid["not an RSA"])
+	assert.Contains(bri.T(), err.Error(), "not an RSA key")
+}
@@ -0,0 +1,118 @@
+package bri
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ReportBRIVAStream is streaming variant of GetReportVA for large report. Report data is decoded one by one
+// and passed to fn instead of being collected in res.Data, so the whole report is never held in memory.
+// Decoding stops at the first error returned by fn and the error is returned.
+// If Client.VerifyResponseSignature is set, the report is read whole and verified before it is passed to fn.
+func (gateway *CoreGateway) ReportBRIVAStream(token string, req GetReportVaRequest, fn func(data VaReportData) error) (res VaReportResponse, err error) {
+	token = "Bearer " + token
+	method := "GET"
+	body := ""
+	timestamp := gateway.Client.timestamp(BRI_TIME_FORMAT)
+	path := vaReportPath(req)
+	signature := gateway.Client.signature(path, method, token, timestamp, body)
+
+	headers := coreHeaders(token, timestamp, signature, "")
+
+	err = gateway.Call(method, path, headers, strings.NewReader(body), &vaReportStream{VaReportResponse: &res, fn: fn}, nil)
+	return
+}
+
+// streamDecoder is implemented by response which is decoded as its body is read, instead of being read whole first.
+// Call passes json decoder of the response body to decodeStream.
+type streamDecoder interface {
+	decodeStream(dec *json.Decoder) error
+}
+
+// vaReportStream decodes VA report response, passing every report data to fn
+type vaReportStream struct {
+	*VaReportResponse
+	fn func(data VaReportData) error
+}
+
+func (s *vaReportStream) decodeStream(dec *json.Decoder) error {
+	return decodeVaReportStream(dec, s.VaReportResponse, s.fn)
+}
+
+// decodeVaReportStream decodes VA report response, passing every item of data array to fn.
+// Other fields are decoded into res.
+func decodeVaReportStream(dec *json.Decoder, res *VaReportResponse, fn func(data VaReportData) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	fields := map[string]json.RawMessage{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := t.(string)
+
+		if key != "data" {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return err
+			}
+			fields[key] = raw
+			continue
+		}
+
+		t, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		// data is null if there is no transaction
+		if t == nil {
+			continue
+		}
+		if delim, ok := t.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("%w: unexpected report data %v", ErrUnexpectedResponse, t)
+		}
+
+		for dec.More() {
+			var data VaReportData
+			if err = dec.Decode(&data); err != nil {
+				return err
+			}
+			if err = fn(data); err != nil {
+				return err
+			}
+		}
+
+		if err = expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, res)
+}
+
+// expectDelim reads next json token and returns error if it isn't the expected delimiter
+func expectDelim(dec *json.Decoder, expected json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := t.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("%w: expected %s but got %v", ErrUnexpectedResponse, expected, t)
+	}
+
+	return nil
+}
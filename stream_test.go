@@ -0,0 +1,116 @@
+package bri
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestReportBRIVAStream() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":true,"responseCode":"00","data":[
+			{"brivaNo":"77777","custCode":"1","amount":"10000.00"},
+			{"brivaNo":"77777","custCode":"2","amount":"20000.00"}],"responseDescription":"Success"}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+
+	var custCodes []string
+	resp, err := coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, func(data VaReportData) error {
+		custCodes = append(custCodes, data.CustCode)
+		return nil
+	})
+
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), []string{"1", "2"}, custCodes)
+	assert.Equal(bri.T(), true, resp.Status)
+	assert.Equal(bri.T(), "Success", resp.Description)
+	assert.Equal(bri.T(), 0, len(resp.Data))
+	assert.Equal(bri.T(), http.StatusOK, resp.RawResponse().StatusCode)
+
+	stop := errors.New("stop")
+	custCodes = nil
+	_, err = coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, func(data VaReportData) error {
+		custCodes = append(custCodes, data.CustCode)
+		return stop
+	})
+
+	assert.True(bri.T(), errors.Is(err, stop))
+	assert.Equal(bri.T(), []string{"1"}, custCodes)
+}
+
+func (bri *BriSanguTestSuite) TestReportBRIVAStreamSharesCallPipeline() {
+	const report = `{"status":true,"responseCode":"00","data":[{"brivaNo":"77777","custCode":"1","amount":"10000.00"}]}`
+	var timestamps []string
+	var status int
+	responseSecret := bri.client.ClientSecret
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get("BRI-Timestamp")
+		timestamps = append(timestamps, timestamp)
+		assert.Equal(bri.T(), generateSignature(vaReportPath(GetReportVaRequest{}), "GET", "Bearer token", timestamp, string(body), bri.client.ClientSecret), r.Header.Get("BRI-Signature"))
+
+		// first attempt fails, so the retry is re-signed
+		if len(timestamps) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("BRI-Timestamp", "2020-01-01T00:00:00.000Z")
+		w.Header().Set("BRI-Signature", generateResponseSignature("2020-01-01T00:00:00.000Z", report, responseSecret))
+		w.WriteHeader(status)
+		w.Write([]byte(report))
+	}))
+	defer server.Close()
+
+	now := time.Date(2021, 11, 2, 13, 0, 0, 0, time.UTC)
+	bri.client.BaseUrl = server.URL
+	bri.client.Now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	coreGateway := CoreGateway{
+		Client: bri.client,
+	}
+	count := func(data VaReportData) error { return nil }
+
+	status = http.StatusOK
+	_, err := coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, count)
+	assert.Equal(bri.T(), nil, err)
+	bri.Require().Equal(2, len(timestamps))
+	assert.NotEqual(bri.T(), timestamps[0], timestamps[1])
+
+	// response signature is verified
+	coreGateway.Client.VerifyResponseSignature = true
+	_, err = coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, count)
+	assert.Equal(bri.T(), nil, err)
+	responseSecret = "other-secret"
+	_, err = coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, count)
+	assert.True(bri.T(), errors.Is(err, ErrInvalidResponseSignature))
+	coreGateway.Client.VerifyResponseSignature = false
+
+	status = http.StatusUnauthorized
+	_, err = coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, count)
+	assert.True(bri.T(), errors.Is(err, ErrUnauthorized))
+
+	// request is never signed with empty secret
+	coreGateway.Client.ClientSecret = ""
+	_, err = coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, count)
+	assert.Equal(bri.T(), ErrMissingClientSecret, err)
+
+	// unreachable host is retry safe
+	coreGateway.Client.ClientSecret = bri.client.ClientSecret
+	coreGateway.Client.BaseUrl = "http://127.0.0.1:1"
+	_, err = coreGateway.ReportBRIVAStream("token", GetReportVaRequest{}, count)
+	var callErr *Error
+	bri.Require().True(errors.As(err, &callErr))
+	assert.True(bri.T(), callErr.IsRetrySafe())
+}
@@ -0,0 +1,231 @@
+package bri
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is subtracted from token expiry, so token is refreshed before BRI expires it
+var tokenExpiryMargin = 1 * time.Minute
+
+// gatewayInitMu guards lazy initialization of gateway unexported state
+var gatewayInitMu sync.Mutex
+
+// TokenStore stores access token used by CoreGateway.AccessToken.
+// Implement it using shared storage, e.g. redis, so instances of a horizontally scaled service share one token.
+// A store holds token of a single credential set.
+type TokenStore interface {
+	// Get returns stored token and its expiry, ok is false if there is no token
+	Get(ctx context.Context) (token string, expiry time.Time, ok bool)
+	// Set stores token and its expiry
+	Set(ctx context.Context, token string, expiry time.Time) error
+}
+
+// MemoryTokenStore is in-memory TokenStore, used by default if Client.TokenStore is not set
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	token  string
+	expiry time.Time
+}
+
+// NewMemoryTokenStore creates in-memory TokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Get returns stored token and its expiry
+func (s *MemoryTokenStore) Get(ctx context.Context) (string, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.token, s.expiry, s.token != ""
+}
+
+// Set stores token and its expiry
+func (s *MemoryTokenStore) Set(ctx context.Context, token string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+	s.expiry = expiry
+	return nil
+}
+
+// tokenCache holds token state of a gateway
+type tokenCache struct {
+	mu      sync.Mutex
+	refresh *tokenRefresh
+	store   *MemoryTokenStore
+}
+
+// tokenRefresh is an in-flight token request shared by concurrent AccessToken callers
+type tokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// tokens returns gateway token cache. Every gateway has its own cache,
+// so gateways with different credential can be used concurrently.
+func (gateway *CoreGateway) tokens() *tokenCache {
+	gatewayInitMu.Lock()
+	defer gatewayInitMu.Unlock()
+
+	if gateway.tokenCache == nil {
+		gateway.tokenCache = &tokenCache{
+			store: NewMemoryTokenStore(),
+		}
+	}
+
+	return gateway.tokenCache
+}
+
+// tokenStore returns Client.TokenStore if it is set, otherwise gateway in-memory store
+func (gateway *CoreGateway) tokenStore() TokenStore {
+	if gateway.Client.TokenStore != nil {
+		return gateway.Client.TokenStore
+	}
+
+	return gateway.tokens().store
+}
+
+// validToken returns stored token if it is not about to expire
+func validToken(ctx context.Context, store TokenStore) (string, bool) {
+	token, expiry, ok := store.Get(ctx)
+	if !ok || token == "" || !time.Now().Add(tokenExpiryMargin).Before(expiry) {
+		return "", false
+	}
+
+	return token, true
+}
+
+// AccessToken returns stored access token, or requests a new one using GetToken and stores it if there is no token or it is about to expire.
+// Token is stored in Client.TokenStore, or in gateway in-memory store if it is not set.
+// Concurrent callers share a single token request, a caller stops waiting for it when ctx is done.
+func (gateway *CoreGateway) AccessToken(ctx context.Context) (string, error) {
+	store := gateway.tokenStore()
+	if token, ok := validToken(ctx, store); ok {
+		return token, nil
+	}
+
+	cache := gateway.tokens()
+	cache.mu.Lock()
+	refresh := cache.refresh
+	if refresh == nil {
+		// token may have been refreshed by a request that just finished
+		if token, ok := validToken(ctx, store); ok {
+			cache.mu.Unlock()
+			return token, nil
+		}
+
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		cache.refresh = refresh
+		go gateway.refreshToken(cache, refresh)
+	}
+	cache.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// refreshToken requests a new token and stores it, then releases callers waiting on refresh.
+// It is not bound to a caller context, so a cancelled caller does not fail the others; GetToken is bounded by Client.TokenTimeout.
+func (gateway *CoreGateway) refreshToken(cache *tokenCache, refresh *tokenRefresh) {
+	refresh.token, refresh.err = gateway.requestToken(context.Background(), gateway.tokenStore())
+
+	cache.mu.Lock()
+	cache.refresh = nil
+	cache.mu.Unlock()
+
+	close(refresh.done)
+}
+
+// requestToken requests a new token bound to ctx and stores it
+func (gateway *CoreGateway) requestToken(ctx context.Context, store TokenStore) (string, error) {
+	res, err := gateway.getToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if res.AccessToken == "" {
+		return "", ErrEmptyAccessToken
+	}
+
+	expiresIn, err := strconv.Atoi(res.ExpiredTime)
+	if err != nil {
+		return "", err
+	}
+
+	expiry := time.Now().Add(time.Duration(expiresIn) * time.Second)
+	if err = store.Set(ctx, res.AccessToken, expiry); err != nil {
+		return "", err
+	}
+
+	return res.AccessToken, nil
+}
+
+// WithAccessToken calls fn with access token from AccessToken. If fn returns ErrUnauthorized, e.g. because the token
+// is revoked or expired before its expiry, the token is refreshed and fn is called once more with the new token.
+// BRI doesn't process a request rejected with 401, so fn is safe to be called again.
+func (gateway *CoreGateway) WithAccessToken(ctx context.Context, fn func(token string) error) error {
+	token, err := gateway.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(token); !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	if err = gateway.invalidateToken(ctx, token); err != nil {
+		return err
+	}
+
+	if token, err = gateway.AccessToken(ctx); err != nil {
+		return err
+	}
+
+	return fn(token)
+}
+
+// invalidateToken removes token from the store, unless it has been replaced by a concurrent refresh
+func (gateway *CoreGateway) invalidateToken(ctx context.Context, token string) error {
+	store := gateway.tokenStore()
+	if stored, _, ok := store.Get(ctx); !ok || stored != token {
+		return nil
+	}
+
+	return store.Set(ctx, "", time.Time{})
+}
+
+// TokenExpiry returns expiry of the stored access token, ok is false if there is no token.
+// AccessToken refreshes the token a minute before it expires, call it earlier to refresh during low traffic.
+func (gateway *CoreGateway) TokenExpiry(ctx context.Context) (expiry time.Time, ok bool) {
+	token, expiry, ok := gateway.tokenStore().Get(ctx)
+	if !ok || token == "" {
+		return time.Time{}, false
+	}
+
+	return expiry, true
+}
+
+// TimeUntilTokenExpiry returns remaining validity of the stored access token, zero if there is no token or it is expired
+func (gateway *CoreGateway) TimeUntilTokenExpiry(ctx context.Context) time.Duration {
+	expiry, ok := gateway.TokenExpiry(ctx)
+	if !ok {
+		return 0
+	}
+
+	if remaining := time.Until(expiry); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
@@ -0,0 +1,166 @@
+package bri
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (bri *BriSanguTestSuite) TestAccessTokenIndependentCache() {
+	ctx := context.Background()
+
+	merchantA := bri.client
+	merchantA.ClientId = "merchant-a"
+	merchantB := bri.client
+	merchantB.ClientId = "merchant-b"
+
+	gatewayA := CoreGateway{Client: merchantA}
+	gatewayB := CoreGateway{Client: merchantB}
+
+	gatewayA.tokens().store.Set(ctx, "token-a", time.Now().Add(time.Hour))
+	gatewayB.tokens().store.Set(ctx, "token-b", time.Now().Add(time.Hour))
+
+	tokenA, err := gatewayA.AccessToken(ctx)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "token-a", tokenA)
+
+	tokenB, err := gatewayB.AccessToken(ctx)
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "token-b", tokenB)
+}
+
+func (bri *BriSanguTestSuite) TestAccessTokenCustomStore() {
+	ctx := context.Background()
+
+	store := NewMemoryTokenStore()
+	store.Set(ctx, "shared-token", time.Now().Add(time.Hour))
+
+	bri.client.TokenStore = store
+	gateway1 := CoreGateway{Client: bri.client}
+	gateway2 := CoreGateway{Client: bri.client}
+
+	token1, err := gateway1.AccessToken(ctx)
+	assert.Equal(bri.T(), nil, err)
+	token2, err := gateway2.AccessToken(ctx)
+	assert.Equal(bri.T(), nil, err)
+
+	assert.Equal(bri.T(), "shared-token", token1)
+	assert.Equal(bri.T(), "shared-token", token2)
+}
+
+func (bri *BriSanguTestSuite) TestTokenExpiry() {
+	ctx := context.Background()
+	gateway := CoreGateway{Client: bri.client}
+
+	_, ok := gateway.TokenExpiry(ctx)
+	assert.False(bri.T(), ok)
+	assert.Equal(bri.T(), time.Duration(0), gateway.TimeUntilTokenExpiry(ctx))
+
+	expiry := time.Now().Add(time.Hour)
+	gateway.tokens().store.Set(ctx, "token", expiry)
+
+	tokenExpiry, ok := gateway.TokenExpiry(ctx)
+	assert.True(bri.T(), ok)
+	assert.Equal(bri.T(), expiry, tokenExpiry)
+	assert.True(bri.T(), gateway.TimeUntilTokenExpiry(ctx) > 59*time.Minute)
+
+	gateway.tokens().store.Set(ctx, "token", time.Now().Add(-time.Minute))
+	assert.Equal(bri.T(), time.Duration(0), gateway.TimeUntilTokenExpiry(ctx))
+}
+
+func (bri *BriSanguTestSuite) TestAccessTokenCoalesceRefresh() {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"access_token":"fresh-token","expires_in":"3599"}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	gateway := CoreGateway{Client: bri.client}
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 20)
+	errs := make([]error, 20)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], errs[i] = gateway.AccessToken(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(bri.T(), int32(1), atomic.LoadInt32(&hits))
+	for i := range tokens {
+		assert.Equal(bri.T(), nil, errs[i])
+		assert.Equal(bri.T(), "fresh-token", tokens[i])
+	}
+
+	// a cancelled caller stops waiting, the refresh still completes for the others
+	gateway.tokens().store.Set(context.Background(), "", time.Time{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := gateway.AccessToken(ctx)
+	assert.Equal(bri.T(), context.DeadlineExceeded, err)
+
+	token, err := gateway.AccessToken(context.Background())
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), "fresh-token", token)
+	assert.Equal(bri.T(), int32(2), atomic.LoadInt32(&hits))
+
+	// token request is bound to its ctx
+	cancel()
+	_, err = gateway.requestToken(ctx, gateway.tokenStore())
+	assert.Contains(bri.T(), err.Error(), context.DeadlineExceeded.Error())
+	assert.Equal(bri.T(), int32(2), atomic.LoadInt32(&hits))
+}
+
+func (bri *BriSanguTestSuite) TestWithAccessTokenRefreshOnUnauthorized() {
+	var tokenHits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/client_credential/accesstoken" {
+			n := atomic.AddInt32(&tokenHits, 1)
+			w.Write([]byte(`{"access_token":"token-` + strconv.Itoa(int(n)) + `","expires_in":"3599"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":false,"responseCode":"01","responseDescription":"Access Token Invalid"}`))
+			return
+		}
+		w.Write([]byte(`{"status":true,"responseCode":"00"}`))
+	}))
+	defer server.Close()
+
+	bri.client.BaseUrl = server.URL
+	gateway := CoreGateway{Client: bri.client}
+
+	var tokens []string
+	var res VaResponse
+	err := gateway.WithAccessToken(context.Background(), func(token string) (err error) {
+		tokens = append(tokens, token)
+		res, err = gateway.CreateVA(token, CreateVaRequest{InstitutionCode: "J104408", BrivaNo: "77777", CustCode: "1", Amount: "10000"})
+		return err
+	})
+	assert.Equal(bri.T(), nil, err)
+	assert.Equal(bri.T(), []string{"token-1", "token-2"}, tokens)
+	assert.Equal(bri.T(), VA_RESP_CODE_SUCCESS, res.ResponseCode)
+
+	// it gives up after a single refresh
+	tokens = nil
+	err = gateway.WithAccessToken(context.Background(), func(token string) error {
+		tokens = append(tokens, token)
+		return ErrUnauthorized
+	})
+	assert.Equal(bri.T(), ErrUnauthorized, err)
+	assert.Equal(bri.T(), []string{"token-2", "token-3"}, tokens)
+}
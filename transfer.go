@@ -0,0 +1,178 @@
+package bri
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	urlTransferIntrabank  = "/v3/transfer/internal"    // POST
+	urlTransferInterbank  = "/v2/transfer/external"    // POST
+	urlTransferStatus     = "/v3/transfer/status"      // POST
+	urlAccountInquiry     = "/v2/transfer/accounts"    // GET
+	urlListBanks          = "/v2/transfer/banks"       // GET
+	urlBulkTransfer       = "/v1/transfer/bulk"        // POST
+	urlBulkTransferStatus = "/v1/transfer/bulk/status" // POST
+)
+
+// Transfer status value
+const (
+	TransferStatusSuccess = "SUCCESS"
+	TransferStatusPending = "PENDING"
+	TransferStatusFailed  = "FAILED"
+)
+
+// TransferIntrabank transfers fund from merchant BRI account to another BRI account.
+// Set req.ExternalID to a value unique per disbursement, so retrying a failed call will not send the fund twice.
+func (g *CoreGateway) TransferIntrabank(token string, req IntrabankTransferRequest) (res TransferResponse, err error) {
+	if err = validateIDRAmount(req.Amount, CurrencyIDR); err != nil {
+		return
+	}
+
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlTransferIntrabank, method, token, timestamp, string(body))
+
+	externalID := req.ExternalID
+	if externalID == "" {
+		externalID = generateSha1Timestamp("transfer-intrabank")
+	}
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["BRI-External-Id"] = externalID
+
+	err = g.Call(method, urlTransferIntrabank, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// TransferInterbank transfers fund from merchant BRI account to account on other bank identified by req.BankCode.
+// Set req.ExternalID to a value unique per disbursement, so retrying a failed call will not send the fund twice.
+func (g *CoreGateway) TransferInterbank(token string, req InterbankTransferRequest) (res TransferResponse, err error) {
+	if err = validateIDRAmount(req.Amount, CurrencyIDR); err != nil {
+		return
+	}
+
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlTransferInterbank, method, token, timestamp, string(body))
+
+	externalID := req.ExternalID
+	if externalID == "" {
+		externalID = generateSha1Timestamp("transfer-interbank")
+	}
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["BRI-External-Id"] = externalID
+
+	err = g.Call(method, urlTransferInterbank, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// TransferStatus inquires the outcome of a fund transfer by its reference (NoReferral).
+// Transfer may settle asynchronously, so TransferStatusPending should be inquired again later.
+func (g *CoreGateway) TransferStatus(token string, req TransferStatusRequest) (res TransferStatusResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlTransferStatus, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.Call(method, urlTransferStatus, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// InquiryAccount returns registered account holder name of account number on bank identified by req.BankCode.
+// Use it to validate beneficiary before transfer. ErrAccountNotFound is returned if BRI can't find the account.
+func (g *CoreGateway) InquiryAccount(token string, req AccountInquiryRequest) (res AccountInquiryResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodGet
+	body := ""
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+
+	query := url.Values{}
+	query.Set("bankcode", req.BankCode)
+	query.Set("beneficiaryaccount", req.AccountNumber)
+	path := signaturePath(urlAccountInquiry, query)
+	signature := g.Client.signature(path, method, token, timestamp, body)
+
+	headers := coreHeaders(token, timestamp, signature, "")
+
+	err = g.Call(method, path, headers, strings.NewReader(body), &res, nil)
+	if err != nil {
+		return
+	}
+
+	if res.ResponseCode == AccountInquiryRespCodeNotFound {
+		err = ErrAccountNotFound
+		return
+	}
+
+	return
+}
+
+// ListBanks returns destination banks of interbank transfer and their codes, used as InterbankTransferRequest.BankCode.
+// The list rarely changes, so callers may cache the response instead of calling it per transfer.
+func (g *CoreGateway) ListBanks(token string) (res ListBanksResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodGet
+	body := ""
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlListBanks, method, token, timestamp, body)
+
+	headers := coreHeaders(token, timestamp, signature, "")
+
+	err = g.Call(method, urlListBanks, headers, strings.NewReader(body), &res, nil)
+	return
+}
+
+// BulkTransfer submits many transfers in one request, e.g. payroll disbursement.
+// Response contains the batch reference and acceptance status of every item, items are then processed asynchronously,
+// use BulkTransferStatus to poll the batch to completion.
+// Set req.ExternalID to a value unique per batch, so retrying a failed call will not send the fund twice.
+func (g *CoreGateway) BulkTransfer(token string, req BulkTransferRequest) (res BulkTransferResponse, err error) {
+	for _, item := range req.Items {
+		if err = validateIDRAmount(item.Amount, CurrencyIDR); err != nil {
+			return
+		}
+	}
+
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlBulkTransfer, method, token, timestamp, string(body))
+
+	externalID := req.ExternalID
+	if externalID == "" {
+		externalID = generateSha1Timestamp("bulk-transfer")
+	}
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+	headers["BRI-External-Id"] = externalID
+
+	err = g.Call(method, urlBulkTransfer, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
+
+// BulkTransferStatus inquires status of a bulk transfer batch and its items by batch reference.
+// Batch is complete once its status is no longer TransferStatusPending.
+func (g *CoreGateway) BulkTransferStatus(token string, req BulkTransferStatusRequest) (res BulkTransferStatusResponse, err error) {
+	token = "Bearer " + token
+	method := http.MethodPost
+	body, err := json.Marshal(req)
+	timestamp := g.Client.timestamp(BRI_TIME_FORMAT)
+	signature := g.Client.signature(urlBulkTransferStatus, method, token, timestamp, string(body))
+
+	headers := coreHeaders(token, timestamp, signature, ContentTypeJSON)
+
+	err = g.Call(method, urlBulkTransferStatus, headers, strings.NewReader(string(body)), &res, nil)
+	return
+}
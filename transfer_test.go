@@ -0,0 +1,154 @@
+package bri
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransfer(t *testing.T) {
+	runMockCases(t, []mockCase{
+		{
+			name: "ListBanks",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				assertCoreRequest(t, r, http.MethodGet, urlListBanks)
+
+				w.Write([]byte(`{"responseCode":"0000","responseDescription":"Success","data":[{"bankCode":"002","bankName":"BANK BRI"},{"bankCode":"014","bankName":"BANK BCA"}]}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.ListBanks("token")
+				assert.Equal(t, nil, err)
+				assert.Equal(t, []Bank{{BankCode: "002", BankName: "BANK BRI"}, {BankCode: "014", BankName: "BANK BCA"}}, resp.Data)
+			},
+			requests: 1,
+		},
+		{
+			name: "BulkTransfer",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case urlBulkTransfer:
+					body := assertCoreRequest(t, r, http.MethodPost, urlBulkTransfer)
+					assert.Equal(t, "batch-1", r.Header.Get("BRI-External-Id"))
+					assert.Contains(t, body, `"items":[{"noReferral":"1","bankCode":"002","beneficiaryAccount":"888801000157508","beneficiaryAccountName":"A","amount":"10000.00","remark":"salary"}`)
+					w.Write([]byte(`{"responseCode":"0000","data":{"batchReference":"B1","status":"PENDING","items":[{"noReferral":"1","status":"PENDING"},{"noReferral":"2","status":"FAILED","failureReason":"invalid account"}]}}`))
+				default:
+					assertCoreRequest(t, r, http.MethodPost, urlBulkTransferStatus)
+					w.Write([]byte(`{"responseCode":"0000","data":{"batchReference":"B1","status":"SUCCESS","items":[{"noReferral":"1","status":"SUCCESS","journalSeq":"123"}]}}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.BulkTransfer("token", BulkTransferRequest{
+					ExternalID:    "batch-1",
+					SourceAccount: "888801000157610",
+					Items: []BulkTransferItem{
+						{NoReferral: "1", BankCode: "002", BeneficiaryAccount: "888801000157508", BeneficiaryAccountName: "A", Amount: NewMoney(1000000, "IDR"), Remark: "salary"},
+						{NoReferral: "2", BankCode: "014", BeneficiaryAccount: "1234", BeneficiaryAccountName: "B", Amount: NewMoney(500000, "IDR"), Remark: "salary"},
+					},
+				})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "B1", resp.Data.BatchReference)
+				assert.Equal(t, TransferStatusFailed, resp.Data.Items[1].Status)
+
+				statusResp, err := gateway.BulkTransferStatus("token", BulkTransferStatusRequest{BatchReference: resp.Data.BatchReference})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, TransferStatusSuccess, statusResp.Data.Status)
+				assert.Equal(t, "123", statusResp.Data.Items[0].JournalSeq)
+			},
+			requests: 2,
+		},
+		{
+			name: "TransferIntrabankInterbank",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				body := assertCoreRequest(t, r, http.MethodPost, r.URL.Path)
+				assert.Equal(t, ContentTypeJSON, r.Header.Get("Content-Type"))
+
+				switch r.URL.Path {
+				case urlTransferIntrabank:
+					assert.Equal(t, "intrabank-1", r.Header.Get("BRI-External-Id"))
+					assert.Contains(t, body, `"NoReferral":"1","sourceAccount":"888801000157610","beneficiaryAccount":"888801000157508","Amount":"10000.00"`)
+					w.Write([]byte(`{"responseCode":"0200","responseDescription":"Success","data":{"noReferral":"1","journalSeq":"123","status":"SUCCESS"}}`))
+				case urlTransferInterbank:
+					// external id is generated if it is not set
+					assert.NotEmpty(t, r.Header.Get("BRI-External-Id"))
+					assert.Contains(t, body, `"noReferral":"2","bankCode":"014","sourceAccount":"888801000157610","beneficiaryAccount":"1234","beneficiaryAccountName":"B","Amount":"5000.00"`)
+					w.Write([]byte(`{"responseCode":"0200","responseDescription":"Success","data":{"noReferral":"2","journalSeq":"124","status":"PENDING","fee":"6500.00"}}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				intrabank, err := gateway.TransferIntrabank("token", IntrabankTransferRequest{
+					ExternalID:         "intrabank-1",
+					NoReferral:         "1",
+					SourceAccount:      "888801000157610",
+					BeneficiaryAccount: "888801000157508",
+					Amount:             NewMoney(1000000, "IDR"),
+				})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "123", intrabank.Data.JournalSeq)
+				assert.Equal(t, TransferStatusSuccess, intrabank.Data.Status)
+
+				interbank, err := gateway.TransferInterbank("token", InterbankTransferRequest{
+					NoReferral:             "2",
+					BankCode:               "014",
+					SourceAccount:          "888801000157610",
+					BeneficiaryAccount:     "1234",
+					BeneficiaryAccountName: "B",
+					Amount:                 NewMoney(500000, "IDR"),
+				})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, TransferStatusPending, interbank.Data.Status)
+				assert.Equal(t, int64(650000), interbank.Data.Fee.Value)
+
+				// invalid amount is rejected before sending the request
+				_, err = gateway.TransferIntrabank("token", IntrabankTransferRequest{Amount: NewMoney(1000050, "IDR")})
+				assert.True(t, errors.Is(err, ErrInvalidAmount))
+				_, err = gateway.TransferInterbank("token", InterbankTransferRequest{Amount: NewMoney(0, "IDR")})
+				assert.True(t, errors.Is(err, ErrInvalidAmount))
+				_, err = gateway.TransferInterbank("token", InterbankTransferRequest{Amount: NewMoney(1000000, "USD")})
+				assert.True(t, errors.Is(err, ErrInvalidAmount))
+			},
+			requests: 2,
+		},
+		{
+			name: "TransferStatus",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				body := assertCoreRequest(t, r, http.MethodPost, urlTransferStatus)
+				assert.Equal(t, `{"noReferral":"2","transactionDate":"2021-11-02"}`, body)
+
+				w.Write([]byte(`{"responseCode":"0300","responseDescription":"Success","data":{"noReferral":"2","amount":"5000.00","status":"FAILED","failureReason":"account closed"}}`))
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.TransferStatus("token", TransferStatusRequest{NoReferral: "2", TransactionDate: "2021-11-02"})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, TransferStatusFailed, resp.Data.Status)
+				assert.Equal(t, "account closed", resp.Data.FailureReason)
+			},
+			requests: 1,
+		},
+		{
+			name: "InquiryAccount",
+			serve: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				assertCoreRequest(t, r, http.MethodGet, urlAccountInquiry)
+
+				switch r.URL.Query().Get("beneficiaryaccount") {
+				case "888801000157508":
+					assert.Equal(t, "002", r.URL.Query().Get("bankcode"))
+					w.Write([]byte(`{"responseCode":"0100","responseDescription":"Inquiry Success","data":{"bankCode":"002","beneficiaryAccount":"888801000157508","beneficiaryAccountName":"Kitabisa"}}`))
+				default:
+					w.Write([]byte(`{"responseCode":"0105","responseDescription":"Account not found"}`))
+				}
+			},
+			call: func(t *testing.T, gateway CoreGateway) {
+				resp, err := gateway.InquiryAccount("token", AccountInquiryRequest{BankCode: "002", AccountNumber: "888801000157508"})
+				assert.Equal(t, nil, err)
+				assert.Equal(t, "Kitabisa", resp.Data.AccountName)
+
+				resp, err = gateway.InquiryAccount("token", AccountInquiryRequest{BankCode: "002", AccountNumber: "1234"})
+				assert.Equal(t, ErrAccountNotFound, err)
+				assert.Equal(t, AccountInquiryRespCodeNotFound, resp.ResponseCode)
+			},
+			requests: 2,
+		},
+	})
+}